@@ -0,0 +1,76 @@
+package docbasecli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/micheam/go-docbase"
+)
+
+// DefaultConcurrency 複数メモを一括処理する際の既定の並列数
+const DefaultConcurrency = 4
+
+// PostFunc は、ID で指定されたメモに対する操作を表す。
+// 操作後のメモが得られる場合はそれを返す (得られない場合は nil)
+type PostFunc func(ctx context.Context, id docbase.PostID) (*docbase.Post, error)
+
+// PostResult は、メモ１件に対する操作結果
+type PostResult struct {
+	ID   docbase.PostID
+	Post *docbase.Post
+	Err  error
+}
+
+// BatchPosts は、ids のそれぞれに fn を最大 concurrency 並列で適用する。
+// 結果は ids と同じ順序で返す。
+func BatchPosts(ctx context.Context, ids []docbase.PostID, concurrency int, fn PostFunc) []PostResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]PostResult, len(ids))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i].ID = ids[i]
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Post, results[i].Err = fn(ctx, ids[i])
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// OutputPostResults は、一括処理の結果を１件１行で出力する。
+// 失敗したものが含まれる場合はエラーを返す。
+func OutputPostResults(out io.Writer, action string, results []PostResult) error {
+	var failed int
+	for _, r := range results {
+		var err error
+		switch {
+		case r.Err != nil:
+			failed++
+			_, err = fmt.Fprintf(out, "%d\tfailed\t%v\n", r.ID, r.Err)
+		case r.Post != nil:
+			_, err = fmt.Fprintf(out, "%d\t%s\t%s\n", r.ID, action, summarizePost(*r.Post))
+		default:
+			_, err = fmt.Fprintf(out, "%d\t%s\n", r.ID, action)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d posts failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestBatchPosts(t *testing.T) {
+	var running, peak int32
+	fn := func(_ context.Context, id docbase.PostID) (*docbase.Post, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		if id == 2 {
+			return nil, errors.New("boom")
+		}
+		return &docbase.Post{ID: id, Title: "title"}, nil
+	}
+	ids := []docbase.PostID{1, 2, 3, 4, 5}
+	got := BatchPosts(context.Background(), ids, 2, fn)
+	if len(got) != len(ids) {
+		t.Fatalf("want %d results, got %d", len(ids), len(got))
+	}
+	for i := range ids {
+		if got[i].ID != ids[i] {
+			t.Errorf("result[%d].ID: want %d, got %d", i, ids[i], got[i].ID)
+		}
+		if (got[i].Err != nil) != (ids[i] == 2) {
+			t.Errorf("result[%d].Err: unexpected %v", i, got[i].Err)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("concurrency exceeded: %d", peak)
+	}
+}
+
+func TestOutputPostResults(t *testing.T) {
+	results := []PostResult{
+		{ID: 1, Post: &docbase.Post{ID: 1, Title: "first", Archived: true}},
+		{ID: 2, Err: errors.New("docbase api returns NG: 404 Not Found")},
+		{ID: 3},
+	}
+	buf := new(bytes.Buffer)
+	err := OutputPostResults(buf, "archived", results)
+	if err == nil || err.Error() != "1 of 3 posts failed" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	want := "1\tarchived\t[archived] first\n" +
+		"2\tfailed\tdocbase api returns NG: 404 Not Found\n" +
+		"3\tarchived\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("output mismatch (-want, +got):%s\n", diff)
+	}
+}
@@ -0,0 +1,365 @@
+package docbasecli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/micheam/go-docbase"
+)
+
+// PostState は、一括操作で変更されうるメモの属性
+type PostState struct {
+	Tags     []string `json:"tags"`
+	Scope    string   `json:"scope"`
+	Groups   []int    `json:"groups"`
+	Archived bool     `json:"archived"`
+}
+
+// StateOf は、メモの現在の属性を返す
+func StateOf(post docbase.Post) PostState {
+	s := PostState{
+		Tags:     make([]string, 0, len(post.Tags)),
+		Scope:    string(post.Scope),
+		Groups:   PostGroupIDs(post),
+		Archived: post.Archived,
+	}
+	for _, t := range post.Tags {
+		s.Tags = append(s.Tags, t.Name)
+	}
+	return s
+}
+
+// PostGroupIDs は、メモの公開先グループの ID を返す
+func PostGroupIDs(post docbase.Post) []int {
+	ids := make([]int, 0, len(post.Groups))
+	for _, g := range post.Groups {
+		m, ok := g.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := m["id"].(float64); ok {
+			ids = append(ids, int(id))
+		}
+	}
+	return ids
+}
+
+// PostGroupNames は、メモの公開先グループの名前を返す
+func PostGroupNames(post docbase.Post) []string {
+	names := make([]string, 0, len(post.Groups))
+	for _, g := range post.Groups {
+		m, ok := g.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := m["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Equal は、s と o が同じ属性を表すかを判定する
+func (s PostState) Equal(o PostState) bool {
+	return s.Scope == o.Scope && s.Archived == o.Archived &&
+		equalStrings(s.Tags, o.Tags) && equalInts(s.Groups, o.Groups)
+}
+
+// Describe は、s から o への変更内容を人が読める形式で返す
+func (s PostState) Describe(o PostState) string {
+	var changes []string
+	for _, t := range o.Tags {
+		if !containsString(s.Tags, t) {
+			changes = append(changes, "+#"+t)
+		}
+	}
+	for _, t := range s.Tags {
+		if !containsString(o.Tags, t) {
+			changes = append(changes, "-#"+t)
+		}
+	}
+	if s.Scope != o.Scope {
+		changes = append(changes, fmt.Sprintf("scope:%s->%s", s.Scope, o.Scope))
+	}
+	if !equalInts(s.Groups, o.Groups) {
+		changes = append(changes, fmt.Sprintf("groups:%v->%v", s.Groups, o.Groups))
+	}
+	if s.Archived != o.Archived {
+		if o.Archived {
+			changes = append(changes, "archive")
+		} else {
+			changes = append(changes, "unarchive")
+		}
+	}
+	return strings.Join(changes, " ")
+}
+
+// PostChange は、メモの属性に対する変更を表す
+type PostChange func(PostState) PostState
+
+// AddTag は、タグ name を追加する変更を返す
+func AddTag(name string) PostChange {
+	return func(s PostState) PostState {
+		if !containsString(s.Tags, name) {
+			s.Tags = append(append([]string{}, s.Tags...), name)
+		}
+		return s
+	}
+}
+
+// RemoveTag は、タグ name を取り除く変更を返す
+func RemoveTag(name string) PostChange {
+	return func(s PostState) PostState {
+		tags := make([]string, 0, len(s.Tags))
+		for _, t := range s.Tags {
+			if t != name {
+				tags = append(tags, t)
+			}
+		}
+		s.Tags = tags
+		return s
+	}
+}
+
+// SetArchived は、アーカイブ状態を変更する変更を返す
+func SetArchived(archived bool) PostChange {
+	return func(s PostState) PostState {
+		s.Archived = archived
+		return s
+	}
+}
+
+// SetScope は、公開範囲を変更する変更を返す
+func SetScope(scope docbase.Scope) PostChange {
+	return func(s PostState) PostState {
+		s.Scope = string(scope)
+		if scope != docbase.ScopeGroup {
+			s.Groups = []int{}
+		}
+		return s
+	}
+}
+
+// MoveGroup は、公開先をグループ groupID のみに変更する変更を返す
+func MoveGroup(groupID int) PostChange {
+	return func(s PostState) PostState {
+		s.Scope = string(docbase.ScopeGroup)
+		s.Groups = []int{groupID}
+		return s
+	}
+}
+
+// ApplyPostState は、メモの属性を before から after に変更する
+func ApplyPostState(ctx context.Context, domain string, id docbase.PostID, before, after PostState) error {
+	fields := docbase.UpdateFields{}
+	var update bool
+	if !equalStrings(before.Tags, after.Tags) {
+		update = true
+		tags := append([]string{}, after.Tags...)
+		fields.Tags = &tags
+	}
+	if before.Scope != after.Scope || !equalInts(before.Groups, after.Groups) {
+		update = true
+		scope, groups := after.Scope, append([]int{}, after.Groups...)
+		fields.Scope = &scope
+		fields.Groups = &groups
+	}
+	if update {
+		err := RetryOnRateLimit(ctx, func() error {
+			_, err := docbase.UpdatePost(ctx, domain, id, nil, fields)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update post(%d): %w", id, err)
+		}
+	}
+	if before.Archived != after.Archived {
+		req := ArchivePostRequest{Domain: domain, ID: id}
+		return RetryOnRateLimit(ctx, func() error {
+			if after.Archived {
+				return ArchivePost(ctx, req)
+			}
+			return UnarchivePost(ctx, req)
+		})
+	}
+	return nil
+}
+
+/***************************************
+ * Bulk Journal
+ ***************************************/
+
+// BulkJournal は、一括操作の内容を記録したもの。
+// UndoBulk に渡すことで操作を取り消すことができる。
+type BulkJournal struct {
+	Domain    string             `json:"domain"`
+	Query     string             `json:"query"`
+	Operation string             `json:"operation"`
+	CreatedAt time.Time          `json:"created_at"`
+	Entries   []BulkJournalEntry `json:"entries"`
+}
+
+type BulkJournalEntry struct {
+	ID      docbase.PostID `json:"id"`
+	Summary string         `json:"summary"`
+	Before  PostState      `json:"before"`
+	After   PostState      `json:"after"`
+	Applied bool           `json:"applied"`
+}
+
+type BulkRequest struct {
+	Domain    string
+	Query     string
+	Operation string
+	Change    PostChange
+}
+
+// PlanBulk は、検索にヒットした全てのメモに Change を適用した結果を計算し、
+// 実際に変更が生じるものを BulkJournal として返す。API への変更は行わない。
+func PlanBulk(ctx context.Context, req BulkRequest) (*BulkJournal, error) {
+	posts, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &req.Query})
+	if err != nil {
+		return nil, err
+	}
+	return planBulk(req, posts), nil
+}
+
+// planBulk は、posts に req.Change を適用し、変更が生じるものを BulkJournal にまとめる
+func planBulk(req BulkRequest, posts []docbase.Post) *BulkJournal {
+	j := &BulkJournal{
+		Domain:    req.Domain,
+		Query:     req.Query,
+		Operation: req.Operation,
+		CreatedAt: time.Now(),
+		Entries:   []BulkJournalEntry{},
+	}
+	for _, post := range posts {
+		before := StateOf(post)
+		after := req.Change(before)
+		if before.Equal(after) {
+			continue
+		}
+		j.Entries = append(j.Entries, BulkJournalEntry{
+			ID:      post.ID,
+			Summary: summarizePost(post),
+			Before:  before,
+			After:   after,
+		})
+	}
+	return j
+}
+
+// ApplyBulk は、j に記録された変更を適用する。
+// 適用できたものは Applied が true になる。
+func ApplyBulk(ctx context.Context, j *BulkJournal, concurrency int) []PostResult {
+	return runBulk(ctx, j, concurrency, false)
+}
+
+// UndoBulk は、j に記録された変更のうち適用済みのものを元に戻す。
+// 戻せたものは Applied が false になる。
+func UndoBulk(ctx context.Context, j *BulkJournal, concurrency int) []PostResult {
+	return runBulk(ctx, j, concurrency, true)
+}
+
+func runBulk(ctx context.Context, j *BulkJournal, concurrency int, undo bool) []PostResult {
+	index := map[docbase.PostID]int{}
+	ids := make([]docbase.PostID, 0, len(j.Entries))
+	for i, e := range j.Entries {
+		if e.Applied == undo {
+			index[e.ID] = i
+			ids = append(ids, e.ID)
+		}
+	}
+	results := BatchPosts(ctx, ids, concurrency, func(ctx context.Context, id docbase.PostID) (*docbase.Post, error) {
+		e := j.Entries[index[id]]
+		from, to := e.Before, e.After
+		if undo {
+			from, to = to, from
+		}
+		return nil, ApplyPostState(ctx, j.Domain, id, from, to)
+	})
+	for _, r := range results {
+		if r.Err == nil {
+			j.Entries[index[r.ID]].Applied = !undo
+		}
+	}
+	return results
+}
+
+// BulkJournalPath は、一括操作の記録を保存する既定のパスを返す
+func BulkJournalPath(t time.Time) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journal", "bulk-"+t.Format("20060102-150405")+".json"), nil
+}
+
+func SaveBulkJournal(path string, j *BulkJournal) error {
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0o600)
+}
+
+func LoadBulkJournal(path string) (*BulkJournal, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	j := new(BulkJournal)
+	if err := json.Unmarshal(b, j); err != nil {
+		return nil, fmt.Errorf("failed to parse journal %q: %w", path, err)
+	}
+	return j, nil
+}
+
+func containsString(ss []string, s string) bool {
+	for i := range ss {
+		if ss[i] == s {
+			return true
+		}
+	}
+	return false
+}
+
+func equalStrings(a, b []string) bool {
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalInts(a, b []int) bool {
+	a, b = append([]int{}, a...), append([]int{}, b...)
+	sort.Ints(a)
+	sort.Ints(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
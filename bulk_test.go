@@ -0,0 +1,138 @@
+package docbasecli
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestPostChange(t *testing.T) {
+	base := PostState{
+		Tags:   []string{"a", "b"},
+		Scope:  "group",
+		Groups: []int{1},
+	}
+	tests := []struct {
+		name   string
+		change PostChange
+		want   PostState
+		desc   string
+	}{
+		{
+			name:   "add tag",
+			change: AddTag("c"),
+			want:   PostState{Tags: []string{"a", "b", "c"}, Scope: "group", Groups: []int{1}},
+			desc:   "+#c",
+		},
+		{
+			name:   "add existing tag",
+			change: AddTag("a"),
+			want:   base,
+			desc:   "",
+		},
+		{
+			name:   "remove tag",
+			change: RemoveTag("a"),
+			want:   PostState{Tags: []string{"b"}, Scope: "group", Groups: []int{1}},
+			desc:   "-#a",
+		},
+		{
+			name:   "archive",
+			change: SetArchived(true),
+			want:   PostState{Tags: []string{"a", "b"}, Scope: "group", Groups: []int{1}, Archived: true},
+			desc:   "archive",
+		},
+		{
+			name:   "set scope",
+			change: SetScope(docbase.ScopeEveryone),
+			want:   PostState{Tags: []string{"a", "b"}, Scope: "everyone", Groups: []int{}},
+			desc:   "scope:group->everyone groups:[1]->[]",
+		},
+		{
+			name:   "move group",
+			change: MoveGroup(2),
+			want:   PostState{Tags: []string{"a", "b"}, Scope: "group", Groups: []int{2}},
+			desc:   "groups:[1]->[2]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.change(base)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("state mismatch (-want, +got):%s\n", diff)
+			}
+			if got.Equal(base) != (tt.desc == "") {
+				t.Errorf("Equal: unexpected %v", got.Equal(base))
+			}
+			if diff := cmp.Diff(tt.desc, base.Describe(got)); diff != "" {
+				t.Errorf("Describe mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, base.Tags); diff != "" {
+		t.Errorf("base state must not be modified:%s\n", diff)
+	}
+}
+
+func TestStateOf(t *testing.T) {
+	post := docbase.Post{
+		Tags:     []docbase.Tag{{Name: "x"}},
+		Scope:    docbase.ScopeGroup,
+		Archived: true,
+		Groups: []interface{}{
+			map[string]interface{}{"id": float64(10), "name": "dev"},
+			map[string]interface{}{"id": float64(20), "name": "ops"},
+		},
+	}
+	want := PostState{Tags: []string{"x"}, Scope: "group", Groups: []int{10, 20}, Archived: true}
+	if diff := cmp.Diff(want, StateOf(post)); diff != "" {
+		t.Errorf("state mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestApplyBulk_undo(t *testing.T) {
+	var got []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Path)
+	}))
+	j := &BulkJournal{
+		Domain: "example",
+		Entries: []BulkJournalEntry{
+			{ID: 1, Before: PostState{}, After: PostState{Archived: true}},
+		},
+	}
+	ctx := context.Background()
+	if err := OutputPostResults(io.Discard, "", ApplyBulk(ctx, j, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if !j.Entries[0].Applied {
+		t.Errorf("entry must be marked as applied")
+	}
+
+	path := filepath.Join(t.TempDir(), "journal.json")
+	if err := SaveBulkJournal(path, j); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBulkJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := OutputPostResults(io.Discard, "", UndoBulk(ctx, loaded, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Entries[0].Applied {
+		t.Errorf("entry must be marked as reverted")
+	}
+	want := []string{
+		"PUT /teams/example/posts/1/archive",
+		"PUT /teams/example/posts/1/unarchive",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("requests mismatch (-want, +got):%s\n", diff)
+	}
+}
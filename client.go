@@ -0,0 +1,119 @@
+package docbasecli
+
+// go-docbase が未対応の API (アーカイブ・削除など) を呼び出すための最小限のクライアント。
+// 認証情報は既定では go-docbase と同様に環境変数 DOCBASE_TOKEN から取得し、
+// SetAccessToken で変更できる。
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// apiBaseURL は DocBase API のエンドポイント (テスト時に差し替える)
+var apiBaseURL = "https://api.docbase.io"
+
+// httpClient は、このパッケージが使う HTTP クライアント (http.DefaultClient とは別のもの)
+var httpClient = &http.Client{}
+
+// tokenHeader は、アクセストークンを渡すリクエストヘッダ
+const tokenHeader = "X-Docbasetoken"
+
+// accessToken は、API の呼び出しに使うアクセストークン
+var accessToken = os.Getenv("DOCBASE_TOKEN")
+
+// SetAccessToken は、API の呼び出しに使うアクセストークンを設定する。
+// go-docbase の呼び出しにも使うには、Transport を go-docbase の HTTP クライアントに設定すること。
+func SetAccessToken(token string) {
+	accessToken = token
+}
+
+// Transport は、go-docbase による API の呼び出しに SetAccessToken で設定したトークンを適用する http.RoundTripper。
+// go-docbase は読み込み時の環境変数 DOCBASE_TOKEN と http.DefaultClient を使うため、
+// 利用する側で http.DefaultClient.Transport に設定する。
+// トークンのヘッダを持つリクエスト (DocBase API の呼び出し) のみを書き換え、Webhook などには付けない。
+// あわせてレートリミットの状態を記録する。
+type Transport struct {
+	// Base 実際にリクエストを送る http.RoundTripper。省略した場合は http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if _, ok := req.Header[tokenHeader]; !ok {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(tokenHeader, accessToken)
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	recordRateLimit(resp.Header)
+	// go-docbase のエラーはステータスコードを文字列でしか持たないため、
+	// レートリミット超過は APIError として返し IsRateLimited で判定できるようにする
+	if resp.StatusCode == http.StatusTooManyRequests {
+		_ = resp.Body.Close()
+		return nil, &APIError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return resp, nil
+}
+
+func buildURL(paths ...string) string {
+	return strings.Join(append([]string{apiBaseURL}, paths...), "/")
+}
+
+func newRequest(ctx context.Context, method, _url string, body io.Reader, param url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, _url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("X-Api-Version", "2")
+	req.Header.Add(tokenHeader, accessToken)
+	req.Header.Add("Content-Type", "application/json")
+	if param != nil {
+		req.URL.RawQuery = param.Encode()
+	}
+	return req, nil
+}
+
+// doRequest は req を送信し、レスポンスを v にデコードする。
+// v が nil の場合はレスポンスボディを読み捨て、*[]byte の場合はそのまま格納する。
+func doRequest(req *http.Request, v interface{}) error {
+	log.Println(req.Method, req.URL)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	recordRateLimit(resp.Header)
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if 300 <= resp.StatusCode {
+		log.Println(string(b))
+		return &APIError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	if v == nil || len(b) == 0 {
+		return nil
+	}
+	if raw, ok := v.(*[]byte); ok {
+		*raw = b
+		return nil
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return nil
+}
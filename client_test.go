@@ -0,0 +1,63 @@
+package docbasecli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// useTestServer は、API の呼び出し先を h を処理する httptest.Server に差し替える
+func useTestServer(t *testing.T, h http.Handler) {
+	t.Helper()
+	ts := httptest.NewServer(h)
+	orig := apiBaseURL
+	apiBaseURL = ts.URL
+	t.Cleanup(func() {
+		apiBaseURL = orig
+		ts.Close()
+	})
+}
+
+func TestTransport_rateLimited(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "300")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1600000000")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	// go-docbase と同様にトークンのヘッダを付けて呼び出す
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Add("X-DocBaseToken", "token")
+	client := &http.Client{Transport: Transport{}}
+	_, err := client.Do(req)
+	if !IsRateLimited(err) {
+		t.Errorf("want rate limited error, got %v", err)
+	}
+	rl := LastRateLimit()
+	if rl == nil || rl.Remaining != 0 || !rl.Reset.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("unexpected rate limit: %+v", rl)
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: &APIError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{err: fmt.Errorf("failed to get post: %w", &APIError{StatusCode: http.StatusTooManyRequests}), want: true},
+		{err: &APIError{StatusCode: http.StatusNotFound}, want: false},
+		{err: errors.New("failed to get post(4290)"), want: false},
+	}
+	for _, tt := range tests {
+		if got := IsRateLimited(tt.err); got != tt.want {
+			t.Errorf("IsRateLimited(%v): want %v, got %v", tt.err, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+var batchFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "Skip confirmation prompt",
+	},
+	&cli.IntFlag{
+		Name:  "concurrency",
+		Usage: "`NUM` of posts processed in parallel",
+		Value: docbasecli.DefaultConcurrency,
+	},
+}
+
+var deletePost = &cli.Command{
+	Name:      "delete",
+	Usage:     "Delete posts.",
+	ArgsUsage: "[ID...]",
+	Flags:     batchFlags,
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		domain := c.String("domain")
+		return runBatch(c, "Delete", "deleted",
+			func(ctx context.Context, id docbase.PostID) (*docbase.Post, error) {
+				req := docbasecli.DeletePostRequest{Domain: domain, ID: id, Hooks: profile(c).Hooks}
+				return nil, docbasecli.DeletePost(ctx, req)
+			})
+	},
+}
+
+var archivePost = &cli.Command{
+	Name:      "archive",
+	Usage:     "Archive posts.",
+	ArgsUsage: "[ID...]",
+	Flags:     batchFlags,
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		domain := c.String("domain")
+		return runBatch(c, "Archive", "archived",
+			func(ctx context.Context, id docbase.PostID) (*docbase.Post, error) {
+				req := docbasecli.ArchivePostRequest{Domain: domain, ID: id}
+				if err := docbasecli.ArchivePost(ctx, req); err != nil {
+					return nil, err
+				}
+				return getPost(ctx, domain, id)
+			})
+	},
+}
+
+var unarchivePost = &cli.Command{
+	Name:      "unarchive",
+	Usage:     "Unarchive posts.",
+	ArgsUsage: "[ID...]",
+	Flags:     batchFlags,
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		domain := c.String("domain")
+		return runBatch(c, "Unarchive", "unarchived",
+			func(ctx context.Context, id docbase.PostID) (*docbase.Post, error) {
+				req := docbasecli.ArchivePostRequest{Domain: domain, ID: id}
+				if err := docbasecli.UnarchivePost(ctx, req); err != nil {
+					return nil, err
+				}
+				return getPost(ctx, domain, id)
+			})
+	},
+}
+
+// runBatch は、引数で指定された全てのメモに fn を適用し、結果を出力する。
+// 実行前に確認を求めるが、--yes 指定時や標準入力が端末でない場合は確認を省略する。
+func runBatch(c *cli.Context, verb, done string, fn docbasecli.PostFunc) error {
+	ids, err := postIDArgs(c, true)
+	if err != nil {
+		return err
+	}
+	if !c.Bool("yes") && docbasecli.IsTerminal(os.Stdin) {
+		prompt := fmt.Sprintf("%s %d post(s) (%s)?", verb, len(ids), joinPostIDs(ids))
+		ok, err := docbasecli.Confirm(os.Stdin, os.Stderr, prompt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("canceled")
+		}
+	}
+	results := docbasecli.BatchPosts(c.Context, ids, c.Int("concurrency"), fn)
+	return docbasecli.OutputPostResults(os.Stdout, done, results)
+}
+
+func parsePostIDs(args []string) ([]docbase.PostID, error) {
+	if len(args) == 0 {
+		return nil, errors.New("need to specify target post id")
+	}
+	ids := make([]docbase.PostID, 0, len(args))
+	for _, arg := range args {
+		id, err := docbase.ParsePostID(arg)
+		if err != nil {
+			return nil, fmt.Errorf("illegal post id %q: %w", arg, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func joinPostIDs(ids []docbase.PostID) string {
+	s := make([]string, len(ids))
+	for i := range ids {
+		s[i] = ids[i].String()
+	}
+	return strings.Join(s, ", ")
+}
+
+// getPost は、指定されたメモを取得して返す
+func getPost(ctx context.Context, domain string, id docbase.PostID) (*docbase.Post, error) {
+	var got docbase.Post
+	req := docbasecli.GetPostRequest{Domain: domain, ID: id}
+	err := docbasecli.GetPost(ctx, req, func(_ context.Context, post docbase.Post) error {
+		got = post
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &got, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+var journalFlag = &cli.StringFlag{
+	Name:  "journal",
+	Usage: "`PATH` to write the undo journal (default: under cache dir)",
+}
+
+var bulk = &cli.Command{
+	Name:  "bulk",
+	Usage: "Apply changes to every post matching a search query",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "query",
+			Aliases: []string{"q"},
+			Usage:   "`QUERY` to select target posts",
+		},
+		journalFlag,
+	}, batchFlags...),
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "tag",
+			Usage: "Add or remove a tag",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Add a tag",
+					ArgsUsage: "TAG",
+					Action: func(c *cli.Context) error {
+						if !c.Args().Present() {
+							return errors.New("need to specify tag name")
+						}
+						tag := c.Args().First()
+						return runBulk(c, "tag add "+tag, docbasecli.AddTag(tag))
+					},
+				},
+				{
+					Name:      "remove",
+					Usage:     "Remove a tag",
+					ArgsUsage: "TAG",
+					Action: func(c *cli.Context) error {
+						if !c.Args().Present() {
+							return errors.New("need to specify tag name")
+						}
+						tag := c.Args().First()
+						return runBulk(c, "tag remove "+tag, docbasecli.RemoveTag(tag))
+					},
+				},
+			},
+		},
+		{
+			Name:  "archive",
+			Usage: "Archive posts",
+			Action: func(c *cli.Context) error {
+				return runBulk(c, "archive", docbasecli.SetArchived(true))
+			},
+		},
+		{
+			Name:      "set-scope",
+			Usage:     "Change scope of posts",
+			ArgsUsage: "everyone|group|private",
+			Action: func(c *cli.Context) error {
+				scope := docbase.Scope(c.Args().First())
+				switch scope {
+				case docbase.ScopeEveryone, docbase.ScopeGroup, docbase.ScopePrivate:
+				default:
+					return fmt.Errorf("illegal scope %q", scope)
+				}
+				return runBulk(c, "set-scope "+string(scope), docbasecli.SetScope(scope))
+			},
+		},
+		{
+			Name:      "move-group",
+			Usage:     "Restrict posts to a group",
+			ArgsUsage: "NAME|ID",
+			Action: func(c *cli.Context) error {
+				if !c.Args().Present() {
+					return errors.New("need to specify group name or id")
+				}
+				g, err := docbasecli.ResolveGroup(c.Context, c.String("domain"), c.Args().First())
+				if err != nil {
+					return err
+				}
+				return runBulk(c, "move-group "+g.Name, docbasecli.MoveGroup(g.ID))
+			},
+		},
+		{
+			Name:      "undo",
+			Usage:     "Revert changes recorded in a journal",
+			ArgsUsage: "JOURNAL",
+			Action: func(c *cli.Context) error {
+				if !c.Args().Present() {
+					return errors.New("need to specify journal file")
+				}
+				path := c.Args().First()
+				j, err := docbasecli.LoadBulkJournal(path)
+				if err != nil {
+					return err
+				}
+				if err := confirmBulk(c, "Revert", j); err != nil {
+					return err
+				}
+				results := docbasecli.UndoBulk(c.Context, j, c.Int("concurrency"))
+				if err := docbasecli.SaveBulkJournal(path, j); err != nil {
+					return err
+				}
+				return docbasecli.OutputPostResults(os.Stdout, "reverted", results)
+			},
+		},
+	},
+}
+
+func runBulk(c *cli.Context, operation string, change docbasecli.PostChange) error {
+	if c.String("query") == "" {
+		return errors.New("need to specify --query")
+	}
+	j, err := docbasecli.PlanBulk(c.Context, docbasecli.BulkRequest{
+		Domain:    c.String("domain"),
+		Query:     c.String("query"),
+		Operation: operation,
+		Change:    change,
+	})
+	if err != nil {
+		return err
+	}
+	return applyJournal(c, j)
+}
+
+// applyJournal は、確認の上で j に記録された変更を適用し、
+// 取り消し用に j をファイルに書き出す。
+func applyJournal(c *cli.Context, j *docbasecli.BulkJournal) error {
+	if len(j.Entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No posts to change.")
+		return nil
+	}
+	if err := confirmBulk(c, "Apply", j); err != nil {
+		return err
+	}
+
+	path := c.String("journal")
+	if path == "" {
+		p, err := docbasecli.BulkJournalPath(time.Now())
+		if err != nil {
+			return err
+		}
+		path = p
+	}
+	if err := docbasecli.SaveBulkJournal(path, j); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	results := docbasecli.ApplyBulk(c.Context, j, c.Int("concurrency"))
+	if err := docbasecli.SaveBulkJournal(path, j); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Journal: %s\n", path)
+	return docbasecli.OutputPostResults(os.Stdout, "changed", results)
+}
+
+// confirmBulk は、変更対象の一覧を表示して確認を求める
+func confirmBulk(c *cli.Context, verb string, j *docbasecli.BulkJournal) error {
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, e := range j.Entries {
+		from, to := e.Before, e.After
+		if verb == "Revert" {
+			if !e.Applied {
+				continue
+			}
+			from, to = to, from
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", e.ID, e.Summary, from.Describe(to))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if c.Bool("yes") || !docbasecli.IsTerminal(os.Stdin) {
+		return nil
+	}
+	prompt := fmt.Sprintf("%s %q to %d post(s)?", verb, j.Operation, len(j.Entries))
+	ok, err := docbasecli.Confirm(os.Stdin, os.Stderr, prompt)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("canceled")
+	}
+	return nil
+}
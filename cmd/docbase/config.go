@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var configFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "config",
+		Usage:   "`PATH` of config file (default: ~/.config/docbase/config.toml)",
+		EnvVars: []string{"DOCBASE_CONFIG"},
+	},
+	&cli.StringFlag{
+		Name:    "profile",
+		Usage:   "`NAME` of profile in config file",
+		EnvVars: []string{"DOCBASE_PROFILE"},
+		Value:   docbasecli.DefaultProfile,
+	},
+}
+
+const metadataConfig = "config"
+
+// loadConfig は、設定ファイルから --profile で指定されたプロファイルを読み込み、
+// App の Metadata に保持する。 --domain が指定されていない場合は設定値を適用し、
+// --token が指定されていない場合はプロファイルのアクセストークンを使う。
+func loadConfig(c *cli.Context) error {
+	path := c.String("config")
+	explicit := path != ""
+	if !explicit {
+		dir, err := docbasecli.ConfigDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(dir, "config.toml")
+	}
+	conf := new(docbasecli.Config)
+	f, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err) && !explicit:
+		// 設定ファイルは省略可能
+	case err != nil:
+		return err
+	default:
+		defer func() { _ = f.Close() }()
+		if conf, err = docbasecli.LoadProfile(f, c.String("profile")); err != nil {
+			return err
+		}
+	}
+	if c.App.Metadata == nil {
+		c.App.Metadata = map[string]interface{}{}
+	}
+	c.App.Metadata[metadataConfig] = conf
+	docbasecli.SetAccessToken(docbasecli.ResolveAccessToken(c.String("token"), conf))
+	if c.String("domain") == "" && conf.Domain != "" {
+		return c.Set("domain", conf.Domain)
+	}
+	return nil
+}
+
+// profile は、読み込まれたプロファイルの設定を返す
+func profile(c *cli.Context) *docbasecli.Config {
+	if conf, ok := c.App.Metadata[metadataConfig].(*docbasecli.Config); ok {
+		return conf
+	}
+	return new(docbasecli.Config)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/docbase-cli/pointer"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+var editPost = &cli.Command{
+	Name:      "edit",
+	Usage:     "edit specified post.",
+	ArgsUsage: "[ID]",
+	Flags: append([]cli.Flag{
+		// TODO(micheam): option `--dradt`
+		// TODO(micheam): option `--notice`
+		// TODO(micheam): option `--tags`
+		// TODO(micheam): option `--scope`
+		// TODO(micheam): option `--groups`
+		&cli.StringFlag{
+			Name:    "title",
+			Aliases: []string{"t"},
+			Usage:   "`STR-VAL` for title",
+		},
+		&cli.StringFlag{
+			Name:    "body",
+			Aliases: []string{"b"},
+			Usage:   "`STR-VAL` for body",
+		},
+		&cli.StringFlag{
+			Name:  "body-file",
+			Usage: "`PATH` of input file",
+		},
+		&cli.StringFlag{
+			Name:  "append",
+			Usage: "`TEXT` to append to the body (or the section)",
+		},
+		&cli.StringFlag{
+			Name:  "prepend",
+			Usage: "`TEXT` to prepend to the body (or the section)",
+		},
+		&cli.StringFlag{
+			Name:  "section",
+			Usage: "`HEADING` of section to splice, e.g. \"## Log\"",
+		},
+		&cli.StringFlag{
+			Name:  "replace-with",
+			Usage: "`PATH` of file to replace the section content with",
+		},
+		&cli.BoolFlag{
+			Name:  "from-stdin",
+			Usage: "Read text for --append/--prepend from stdin (default: append)",
+		},
+		lintFlag,
+	}, uploadFlags...),
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		id, err := postIDArg(c)
+		if err != nil {
+			return err
+		}
+		var title *string
+		if c.IsSet("title") {
+			title = pointer.StringPtr(c.String("title"))
+		}
+
+		if c.IsSet("section") && !isSplice(c) {
+			return errors.New("--section requires --append, --prepend, --replace-with or --from-stdin")
+		}
+		if err := checkSpliceFlags(c); err != nil {
+			return err
+		}
+
+		existing, err := getPost(c.Context, c.String("domain"), id)
+		if err != nil {
+			return fmt.Errorf("faild to get existing post(%d): %w", id, err)
+		}
+
+		// Body
+		var (
+			body   string
+			reedit func(string) (string, error)
+		)
+		switch {
+		case isSplice(c):
+			body, err = spliceBody(c, existing.Body)
+		case len(c.String("body")) != 0:
+			body = c.String("body")
+		case len(c.String("body-file")) != 0:
+			var b []byte
+			b, err = ioutil.ReadFile(c.String("body-file"))
+			if err != nil {
+				err = fmt.Errorf("cant open %q: %w", c.String("body-file"), err)
+			}
+			body = string(b)
+		default:
+			reedit = editorFor(id)
+			body, err = reedit(existing.Body)
+		}
+		if err != nil {
+			return err
+		}
+		return uploadEdit(c, existing, title, body, reedit)
+	},
+}
+
+// editorFor は、メモ id の本文をエディタで編集する関数を返す
+func editorFor(id docbase.PostID) func(string) (string, error) {
+	return func(initial string) (string, error) {
+		dir := os.Getenv("DOCBASE_TEMP_DIR")
+		b, err := captureFromEditor(dir, fmt.Sprintf("%010d.*.md", id), initial)
+		return string(b), err
+	}
+}
+
+// editPostInEditor は、existing の本文をエディタで編集してアップロードする
+func editPostInEditor(c *cli.Context, existing docbase.Post) error {
+	edit := editorFor(existing.ID)
+	body, err := edit(existing.Body)
+	if err != nil {
+		return err
+	}
+	return uploadEdit(c, &existing, nil, body, edit)
+}
+
+// uploadEdit は、差分を確認した上で existing のタイトルと本文を title, body に更新する
+func uploadEdit(c *cli.Context, existing *docbase.Post, title *string, body string,
+	reedit func(string) (string, error)) error {
+	body, ok, err := previewUpload(c, *existing, title, body, reedit)
+	if err != nil || !ok {
+		return err
+	}
+	return uploadWithScan(c, func(scanner *docbasecli.SecretScanner) error {
+		req := docbasecli.UpdatePostRequest{
+			Domain:   c.String("domain"),
+			ID:       existing.ID,
+			Body:     strings.NewReader(body),
+			Title:    title,
+			Previous: existing,
+			Scanner:  scanner,
+			Hooks:    profile(c).Hooks,
+		}
+		handle := docbasecli.RecordRevisions(req.Domain, existing, profile(c).UserID, docbasecli.PrintURL(os.Stdout, "Updated."))
+		return docbasecli.UpatePost(c.Context, req, handle)
+	})
+}
+
+var uploadFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:    "yes",
+		Aliases: []string{"y"},
+		Usage:   "Upload without confirmation",
+	},
+	&cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Show the diff only, without uploading",
+	},
+	allowSecretsFlag,
+}
+
+// previewUpload は、existing のタイトルと本文を title, body に変更する差分を表示し、
+// アップロードしてよいかを確認する。アップロードする場合は本文と true を返す。
+// reedit を指定した場合は、確認時にエディタで再編集できる。
+func previewUpload(c *cli.Context, existing docbase.Post, title *string, body string,
+	reedit func(string) (string, error)) (string, bool, error) {
+	var tags []string
+	for _, tag := range existing.Tags {
+		tags = append(tags, tag.Name)
+	}
+	for {
+		var err error
+		if body, err = lintBeforeUpload(c, body, tags); err != nil {
+			return "", false, err
+		}
+		diff := docbasecli.DiffPost(existing, title, body, docbasecli.IsTerminal(os.Stdout))
+		if diff == "" {
+			fmt.Println("No changes.")
+			return "", false, nil
+		}
+		fmt.Print(diff)
+		if c.Bool("dry-run") {
+			return "", false, nil
+		}
+		if c.Bool("yes") || !docbasecli.IsTerminal(os.Stdin) {
+			return body, true, nil
+		}
+		prompt := "Upload? [y/N]"
+		if reedit != nil {
+			prompt = "Upload? [y/N/e(dit again)]"
+		}
+		answer, err := docbasecli.Ask(os.Stdin, os.Stderr, prompt)
+		if err != nil {
+			return "", false, err
+		}
+		switch {
+		case answer == "y" || answer == "yes":
+			return body, true, nil
+		case reedit != nil && (answer == "e" || answer == "edit"):
+			if body, err = reedit(body); err != nil {
+				return "", false, err
+			}
+		default:
+			return "", false, errors.New("canceled")
+		}
+	}
+}
+
+var diffPost = &cli.Command{
+	Name:      "diff",
+	Usage:     "show diff between the post and local file.",
+	ArgsUsage: "ID FILE",
+	Description: `Reads FILE from stdin if "-" is given.
+Exits with status 1 if there are any differences.`,
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		if c.NArg() != 2 {
+			return errors.New("need to specify post id and file")
+		}
+		id, err := docbase.ParsePostID(c.Args().Get(0))
+		if err != nil {
+			return fmt.Errorf("illegal post id: %w", err)
+		}
+		var b []byte
+		if path := c.Args().Get(1); path == "-" {
+			b, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			b, err = ioutil.ReadFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		post, err := getPost(c.Context, c.String("domain"), id)
+		if err != nil {
+			return err
+		}
+		diff := docbasecli.DiffPost(*post, nil, string(b), docbasecli.IsTerminal(os.Stdout))
+		if diff == "" {
+			return nil
+		}
+		fmt.Print(diff)
+		return cli.Exit("", 1)
+	},
+}
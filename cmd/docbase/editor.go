@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+)
+
+// captureFromEditor は、initial を書き込んだ一時ファイルをエディタで開き、
+// 編集後の内容を返す。一時ファイルは dir に pattern の名前で作成される。
+func captureFromEditor(dir, pattern, initial string) ([]byte, error) {
+	tempfile, err := ioutil.TempFile(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(tempfile.Name()) }()
+	i, err := tempfile.Write([]byte(initial))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("write %d bytes of default value", i)
+	b, err := docbasecli.CaptureInputFromEditor(
+		docbasecli.GetPreferredEditorFromEnvironment,
+		tempfile,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("faild to capture input: %w", err)
+	}
+	return b, nil
+}
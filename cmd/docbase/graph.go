@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var backlinks = &cli.Command{
+	Name:      "backlinks",
+	Usage:     "Show posts linking to the post",
+	ArgsUsage: "[ID]",
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		id, err := postIDArg(c)
+		if err != nil {
+			return err
+		}
+		presenter, err := docbasecli.BuildPostCollectionHandler(false)
+		if err != nil {
+			return err
+		}
+		req := docbasecli.BacklinksRequest{Domain: c.String("domain"), ID: id}
+		return docbasecli.Backlinks(c.Context, req, presenter)
+	},
+}
+
+var graph = &cli.Command{
+	Name:  "graph",
+	Usage: "Output link graph of posts for Graphviz",
+	Description: `Only links between posts matching the query are included.
+Render it with Graphviz: docbase graph -q "tag:handbook" | dot -Tsvg > graph.svg`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "query",
+			Aliases: []string{"q"},
+			Usage:   "`QUERY` to select posts",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Usage:   "output `FORMAT` (dot or json)",
+			Value:   string(docbasecli.GraphDOT),
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		format, err := docbasecli.ParseGraphFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+		query, err := docbasecli.ExpandQuery(c.String("query"), profile(c).UserID)
+		if err != nil {
+			return err
+		}
+		req := docbasecli.GraphRequest{Domain: c.String("domain"), Query: query}
+		return docbasecli.Graph(c.Context, req, docbasecli.OutputGraph(os.Stdout, format))
+	},
+}
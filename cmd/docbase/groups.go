@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/docbase-cli/pointer"
+	"github.com/urfave/cli/v2"
+)
+
+var groups = &cli.Command{
+	Name:  "groups",
+	Usage: "Show groups, members and group-scoped posts",
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "List groups",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "name",
+					Usage: "`NAME` to narrow down groups (partial match)",
+				},
+				formatFlag,
+			},
+			Action: func(c *cli.Context) error {
+				format, err := docbasecli.ParseFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				req := docbasecli.ListGroupsRequest{Domain: c.String("domain")}
+				if c.String("name") != "" {
+					req.Name = pointer.StringPtr(c.String("name"))
+				}
+				return docbasecli.ListAllGroups(c.Context, req, docbasecli.OutputGroups(os.Stdout, format))
+			},
+		},
+		{
+			Name:      "show",
+			Usage:     "Show group detail",
+			ArgsUsage: "NAME|ID",
+			Flags:     []cli.Flag{formatFlag},
+			Action: func(c *cli.Context) error {
+				format, err := docbasecli.ParseFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				req, err := getGroupRequest(c)
+				if err != nil {
+					return err
+				}
+				return docbasecli.GetGroup(c.Context, *req, docbasecli.OutputGroupDetail(os.Stdout, format))
+			},
+		},
+		{
+			Name:      "members",
+			Usage:     "List members of group",
+			ArgsUsage: "NAME|ID",
+			Flags:     []cli.Flag{formatFlag},
+			Action: func(c *cli.Context) error {
+				format, err := docbasecli.ParseFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				req, err := getGroupRequest(c)
+				if err != nil {
+					return err
+				}
+				return docbasecli.GetGroup(c.Context, *req, docbasecli.OutputGroupMembers(os.Stdout, format))
+			},
+		},
+		{
+			Name:      "posts",
+			Usage:     "List posts restricted to group",
+			ArgsUsage: "NAME|ID",
+			Flags:     listPostsFlags,
+			Action: func(c *cli.Context) error {
+				if !c.Args().Present() {
+					return errors.New("need to specify group name or id")
+				}
+				g, err := docbasecli.ResolveGroup(c.Context, c.String("domain"), c.Args().First())
+				if err != nil {
+					return err
+				}
+				req, err := buildListPostsRequest(c)
+				if err != nil {
+					return err
+				}
+				req.Query = pointer.StringPtr(withGroupQuery(req.Query, g.Name))
+				presenter, err := docbasecli.BuildPostCollectionHandler(c.Bool("meta"))
+				if err != nil {
+					return err
+				}
+				return docbasecli.ListPosts(c.Context, req, presenter)
+			},
+		},
+	},
+}
+
+func getGroupRequest(c *cli.Context) (*docbasecli.GetGroupRequest, error) {
+	if !c.Args().Present() {
+		return nil, errors.New("need to specify group name or id")
+	}
+	g, err := docbasecli.ResolveGroup(c.Context, c.String("domain"), c.Args().First())
+	if err != nil {
+		return nil, err
+	}
+	return &docbasecli.GetGroupRequest{Domain: c.String("domain"), ID: g.ID}, nil
+}
+
+// withGroupQuery は、検索クエリにグループの絞り込み条件を加える
+func withGroupQuery(query *string, group string) string {
+	q := "group:" + docbasecli.QuoteQuery(group)
+	if query != nil && *query != "" {
+		q = *query + " " + q
+	}
+	return q
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+var history = &cli.Command{
+	Name:      "history",
+	Usage:     "Show revisions of post recorded by this command",
+	ArgsUsage: "[ID]",
+	Description: `DocBase API does not provide edit history,
+so revisions are recorded locally every time this command updates a post.`,
+	Flags: []cli.Flag{formatFlag},
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Action: func(c *cli.Context) error {
+		format, err := docbasecli.ParseFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+		id, err := postIDArg(c)
+		if err != nil {
+			return err
+		}
+		req := docbasecli.ListRevisionsRequest{Domain: c.String("domain"), ID: id}
+		return docbasecli.ListRevisions(c.Context, req, docbasecli.OutputRevisions(os.Stdout, format))
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:      "show",
+			Usage:     "Show body of the revision",
+			ArgsUsage: "ID REV",
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 2 {
+					return errors.New("need to specify post id and revision")
+				}
+				id, err := docbase.ParsePostID(c.Args().Get(0))
+				if err != nil {
+					return fmt.Errorf("illegal post id: %w", err)
+				}
+				rev, err := getRevision(c, id, c.Args().Get(1))
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Print(text.Dos2Unix(rev.Body))
+				return err
+			},
+		},
+		{
+			Name:      "diff",
+			Usage:     "Show diff between two revisions",
+			ArgsUsage: "ID REV1 REV2",
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 3 {
+					return errors.New("need to specify post id and two revisions")
+				}
+				id, err := docbase.ParsePostID(c.Args().Get(0))
+				if err != nil {
+					return fmt.Errorf("illegal post id: %w", err)
+				}
+				from, err := getRevision(c, id, c.Args().Get(1))
+				if err != nil {
+					return err
+				}
+				to, err := getRevision(c, id, c.Args().Get(2))
+				if err != nil {
+					return err
+				}
+				fmt.Print(docbasecli.DiffRevisions(id, *from, *to, docbasecli.IsTerminal(os.Stdout)))
+				return nil
+			},
+		},
+	},
+}
+
+var revert = &cli.Command{
+	Name:      "revert",
+	Usage:     "Restore title and body of post to the revision",
+	ArgsUsage: "ID REV",
+	Flags:     uploadFlags,
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		if c.NArg() != 2 {
+			return errors.New("need to specify post id and revision")
+		}
+		id, err := docbase.ParsePostID(c.Args().Get(0))
+		if err != nil {
+			return fmt.Errorf("illegal post id: %w", err)
+		}
+		rev, err := getRevision(c, id, c.Args().Get(1))
+		if err != nil {
+			return err
+		}
+		existing, err := getPost(c.Context, c.String("domain"), id)
+		if err != nil {
+			return fmt.Errorf("faild to get existing post(%d): %w", id, err)
+		}
+		body, ok, err := previewUpload(c, *existing, &rev.Title, rev.Body, nil)
+		if err != nil || !ok {
+			return err
+		}
+		return uploadWithScan(c, func(scanner *docbasecli.SecretScanner) error {
+			req := docbasecli.UpdatePostRequest{
+				Domain:   c.String("domain"),
+				ID:       id,
+				Body:     strings.NewReader(body),
+				Title:    &rev.Title,
+				Previous: existing,
+				Scanner:  scanner,
+				Hooks:    profile(c).Hooks,
+			}
+			message := fmt.Sprintf("Reverted to revision %d.", rev.Rev)
+			handle := docbasecli.RecordRevisions(req.Domain, existing, profile(c).UserID, docbasecli.PrintURL(os.Stdout, message))
+			return docbasecli.UpatePost(c.Context, req, handle)
+		})
+	},
+}
+
+func getRevision(c *cli.Context, id docbase.PostID, s string) (*docbasecli.Revision, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("illegal revision %q: %w", s, err)
+	}
+	req := docbasecli.GetRevisionRequest{Domain: c.String("domain"), ID: id, Rev: n}
+	return docbasecli.GetRevision(c.Context, req)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+var journal = &cli.Command{
+	Name:  "journal",
+	Usage: "Open or append to today's work memo (create it if absent)",
+	Description: `Searches for the post titled by the daily (or weekly) template.
+If the post does not exist, it is created from the template.
+Text given by --append or stdin is appended under a timestamp heading,
+otherwise the post is opened in the editor.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "weekly",
+			Aliases: []string{"w"},
+			Usage:   "Use weekly memo instead of daily one",
+		},
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "`NAME` of template (default: daily or weekly)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: "`TAG` to find and create the memo with",
+		},
+		&cli.StringFlag{
+			Name:    "append",
+			Aliases: []string{"a"},
+			Usage:   "`TEXT` to append with a timestamp heading (read stdin if it is not a terminal)",
+		},
+		allowSecretsFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		now := time.Now()
+		domain := c.String("domain")
+
+		t, err := journalTemplate(c)
+		if err != nil {
+			return err
+		}
+		t.Tags = append(t.Tags, c.StringSlice("tag")...)
+		rendered, err := t.Render(templateData(c))
+		if err != nil {
+			return err
+		}
+
+		entry := c.String("append")
+		if entry == "" && !docbasecli.IsTerminal(os.Stdin) {
+			b, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			entry = string(b)
+		}
+
+		// Find existing memo
+		var existing *docbase.Post
+		find := docbasecli.FindPostRequest{
+			Domain: domain,
+			Title:  rendered.Title,
+			Tags:   c.StringSlice("tag"),
+			Author: profile(c).UserID,
+		}
+		err = docbasecli.FindPostByTitle(c.Context, find, func(_ context.Context, post docbase.Post) error {
+			existing = &post
+			return nil
+		})
+		if err != nil && !errors.Is(err, docbasecli.ErrNotFound) {
+			return err
+		}
+
+		// Body
+		body := rendered.Body
+		if existing != nil {
+			body = existing.Body
+		}
+		if strings.TrimSpace(entry) != "" {
+			body = docbasecli.AppendJournalEntry(body, now, entry)
+		} else {
+			b, err := captureFromEditor(os.Getenv("DOCBASE_TEMP_DIR"), "journal.*.md", body)
+			if err != nil {
+				return err
+			}
+			body = string(b)
+		}
+
+		if existing != nil {
+			return uploadWithScan(c, func(scanner *docbasecli.SecretScanner) error {
+				req := docbasecli.UpdatePostRequest{
+					Domain:   domain,
+					ID:       existing.ID,
+					Body:     strings.NewReader(body),
+					Previous: existing,
+					Scanner:  scanner,
+					Hooks:    profile(c).Hooks,
+				}
+				handle := docbasecli.RecordRevisions(domain, existing, profile(c).UserID, docbasecli.PrintURL(os.Stdout, "Updated."))
+				return docbasecli.UpatePost(c.Context, req, handle)
+			})
+		}
+		opt, err := postOptionFromTemplate(c, rendered, nil)
+		if err != nil {
+			return err
+		}
+		return uploadWithScan(c, func(scanner *docbasecli.SecretScanner) error {
+			req := docbasecli.CreatePostRequest{
+				Domain:  domain,
+				Title:   rendered.Title,
+				Body:    strings.NewReader(body),
+				Option:  opt,
+				Scanner: scanner,
+				Hooks:   profile(c).Hooks,
+			}
+			return docbasecli.CreatePost(c.Context, req, docbasecli.PrintURL(os.Stdout, "Created."))
+		})
+	},
+}
+
+// journalTemplate は、journal で使うテンプレートを返す。
+// daily/weekly テンプレートが配置されていない場合は、タイトルのみのテンプレートを返す。
+func journalTemplate(c *cli.Context) (*docbasecli.PostTemplate, error) {
+	name := c.String("template")
+	if name != "" {
+		return docbasecli.LoadTemplate(name)
+	}
+	name = "daily"
+	title := profile(c).DefaultTitle
+	if title == "" {
+		title = docbasecli.DefaultTitleTemplate
+	}
+	if c.Bool("weekly") {
+		name, title = "weekly", docbasecli.DefaultWeeklyTitleTemplate
+	}
+	t, err := docbasecli.LoadTemplate(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return &docbasecli.PostTemplate{Name: name, Title: title}, nil
+	}
+	return t, err
+}
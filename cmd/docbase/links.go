@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var links = &cli.Command{
+	Name:  "links",
+	Usage: "Inspect links in posts",
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "check",
+			Usage: "Find links to missing, archived or private posts",
+			Description: `Exits with status 1 when broken links are found.
+Specify --external to check external URLs as well.`,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "query",
+					Aliases: []string{"q"},
+					Usage:   "`QUERY` to select posts to check",
+				},
+				&cli.BoolFlag{
+					Name:  "external",
+					Usage: "Check external URLs with HEAD request",
+				},
+				&cli.IntFlag{
+					Name:  "concurrency",
+					Usage: "`NUM` of links checked in parallel",
+					Value: docbasecli.DefaultConcurrency,
+				},
+				&cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "`DURATION` to wait for each external URL",
+					Value: docbasecli.DefaultLinkTimeout,
+				},
+				formatFlag,
+			},
+			Action: func(c *cli.Context) error {
+				format, err := docbasecli.ParseFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				query, err := docbasecli.ExpandQuery(c.String("query"), profile(c).UserID)
+				if err != nil {
+					return err
+				}
+				req := docbasecli.CheckLinksRequest{
+					Domain:      c.String("domain"),
+					Query:       query,
+					External:    c.Bool("external"),
+					Concurrency: c.Int("concurrency"),
+					Timeout:     c.Duration("timeout"),
+				}
+				var found bool
+				output := docbasecli.OutputLinkReports(os.Stdout, format)
+				err = docbasecli.CheckLinks(c.Context, req, func(ctx context.Context, reports []docbasecli.LinkReport) error {
+					found = len(reports) > 0
+					return output(ctx, reports)
+				})
+				if err != nil {
+					return err
+				}
+				if found {
+					return cli.Exit("", 1)
+				}
+				return nil
+			},
+		},
+	},
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+var lintFlag = &cli.StringFlag{
+	Name:  "lint",
+	Usage: "`MODE` to lint the body before upload: off, warn, error or fix",
+	Value: "off",
+}
+
+var lint = &cli.Command{
+	Name:      "lint",
+	Usage:     "Check markdown style of files or posts",
+	ArgsUsage: "FILE|ID...",
+	Description: `Reads from stdin if "-" is given. Rules are configured in [PROFILE.Lint] of config file.
+Exits with status 1 when any issues remain.
+With --fix, files are rewritten in place, posts are uploaded after confirmation
+and stdin is written to stdout.`,
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "fix",
+			Usage: "Fix auto-fixable issues",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: "`TAG` of the file to check required sections",
+		},
+		formatFlag,
+	}, uploadFlags...),
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		if !c.Args().Present() {
+			return errors.New("need to specify file or post id")
+		}
+		format, err := docbasecli.ParseFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+		cfg := profile(c).Lint
+		var results []docbasecli.LintResult
+		for _, arg := range c.Args().Slice() {
+			r, err := lintTarget(c, arg, cfg)
+			if err != nil {
+				return err
+			}
+			if len(r.Issues) > 0 {
+				results = append(results, *r)
+			}
+		}
+		out := os.Stdout
+		if c.Bool("fix") && c.Args().First() == "-" {
+			// 標準出力には修正した本文を書き出している
+			out = os.Stderr
+		}
+		if err := docbasecli.OutputLintResults(out, format)(c.Context, results); err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		if !c.Bool("fix") {
+			for _, r := range results {
+				if text.HasFixable(r.Issues) {
+					fmt.Fprintln(os.Stderr, "Some issues can be fixed automatically. Rerun with --fix.")
+					break
+				}
+			}
+		}
+		return cli.Exit("", 1)
+	},
+}
+
+// lintTarget は、arg で指定されたファイルもしくはメモを検査する。
+// --fix が指定された場合は修正してから、残った問題を返す。
+func lintTarget(c *cli.Context, arg string, cfg text.LintConfig) (*docbasecli.LintResult, error) {
+	var (
+		body string
+		tags = c.StringSlice("tag")
+		post *docbase.Post
+	)
+	_, statErr := os.Stat(arg)
+	switch {
+	case arg == "-":
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		body = string(b)
+	case statErr == nil:
+		b, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return nil, err
+		}
+		body = string(b)
+	default:
+		id, err := docbase.ParsePostID(arg)
+		if err != nil {
+			return nil, fmt.Errorf("no such file or illegal post id %q", arg)
+		}
+		if post, err = getPost(c.Context, c.String("domain"), id); err != nil {
+			return nil, err
+		}
+		body = post.Body
+		for _, tag := range post.Tags {
+			tags = append(tags, tag.Name)
+		}
+	}
+
+	if c.Bool("fix") {
+		fixed := text.LintFix(body, cfg)
+		switch {
+		case arg == "-":
+			fmt.Print(fixed)
+		case fixed == body:
+		case post != nil:
+			if err := uploadEdit(c, post, nil, fixed, nil); err != nil {
+				return nil, err
+			}
+		default:
+			if err := ioutil.WriteFile(arg, []byte(fixed), 0o644); err != nil {
+				return nil, err
+			}
+		}
+		body = fixed
+	}
+	return &docbasecli.LintResult{Target: arg, Issues: text.Lint(body, tags, cfg)}, nil
+}
+
+// lintBeforeUpload は、--lint の指定に従ってアップロード前の本文を検査する。
+// fix の場合は修正した本文を返し、error の場合は問題があればエラーを返す。
+func lintBeforeUpload(c *cli.Context, body string, tags []string) (string, error) {
+	mode := c.String("lint")
+	switch mode {
+	case "", "off":
+		return body, nil
+	case "warn", "error":
+	case "fix":
+		body = text.LintFix(body, profile(c).Lint)
+	default:
+		return "", fmt.Errorf("unsupported lint mode %q (off, warn, error or fix)", mode)
+	}
+	issues := text.Lint(body, tags, profile(c).Lint)
+	for _, i := range issues {
+		fmt.Fprintf(os.Stderr, "lint:%s\n", i)
+	}
+	if text.HasFixable(issues) {
+		fmt.Fprintln(os.Stderr, "lint:some issues can be fixed automatically with --lint fix")
+	}
+	if mode == "error" && len(issues) > 0 {
+		return "", fmt.Errorf("%d lint issues found", len(issues))
+	}
+	return body, nil
+}
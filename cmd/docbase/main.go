@@ -1,19 +1,19 @@
 package main
 
 import (
-	"bytes"
-	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	docbasecli "github.com/micheam/docbase-cli"
 	"github.com/micheam/docbase-cli/pointer"
+	"github.com/micheam/docbase-cli/text"
 	"github.com/micheam/go-docbase"
 	"github.com/urfave/cli/v2"
 )
@@ -33,6 +33,8 @@ func main() {
 
 func init() {
 	log.SetOutput(io.Discard)
+	// go-docbase は http.DefaultClient を使うため、プロファイルのトークンの適用とレートリミットの記録を行う
+	http.DefaultClient.Transport = docbasecli.Transport{}
 }
 
 func newApp() *cli.App {
@@ -46,7 +48,7 @@ func newApp() *cli.App {
 			Email: "michito.maeda@gmail.com",
 		},
 	}
-	app.Flags = []cli.Flag{
+	app.Flags = append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:    "verbose",
 			Aliases: []string{"vv"},
@@ -62,11 +64,16 @@ func newApp() *cli.App {
 			EnvVars: []string{"DOCBASE_DOMAIN"},
 			Usage:   "`NAME` on docbase.io",
 		},
-	}
+	}, configFlags...)
+	app.Before = loadConfig
 	app.Commands = []*cli.Command{
-		viewPost, listPosts,
-		newPost, editPost,
-		tags,
+		viewPost, listPosts, tui,
+		newPost, editPost, diffPost, history, revert, render, site,
+		deletePost, archivePost, unarchivePost,
+		bulk, lint, scan, links, backlinks, graph, report, stats, watch,
+		tags, groups,
+		whoami, users,
+		templates, journal,
 	}
 	return app
 }
@@ -74,7 +81,7 @@ func newApp() *cli.App {
 var viewPost = &cli.Command{
 	Name:      "view",
 	Usage:     "show post title and body",
-	ArgsUsage: "POST_ID",
+	ArgsUsage: "[POST_ID]",
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name:    "web",
@@ -87,12 +94,21 @@ var viewPost = &cli.Command{
 			Usage:   "`NUM` to display body. set 0 to display full.",
 			Value:   0,
 		},
+		&cli.BoolFlag{
+			Name:  "raw",
+			Usage: "Show raw markdown body without rendering",
+		},
+		&cli.StringFlag{
+			Name:  "style",
+			Usage: "`STYLE` to render markdown: dark, light or notty",
+			Value: string(text.StyleDark),
+		},
 	},
 	Action: func(c *cli.Context) error {
 		if c.Bool("verbose") {
 			log.SetOutput(os.Stderr)
 		}
-		postID, err := docbase.ParsePostID(c.Args().First())
+		postID, err := postIDArg(c)
 		if err != nil {
 			return err
 		}
@@ -106,69 +122,218 @@ var viewPost = &cli.Command{
 		}
 
 		out := os.Stdout
-		if docbasecli.IsTerminal(out) {
+		style, err := text.ParseStyle(c.String("style"))
+		if err != nil {
+			return err
+		}
+		switch {
+		case c.Bool("raw") && docbasecli.IsTerminal(out):
 			return docbasecli.GetPost(
 				c.Context, req, docbasecli.OutputPostDetail(out, c.Int("lines")))
+		case !c.Bool("raw") && (docbasecli.IsTerminal(out) || c.IsSet("style")):
+			return docbasecli.GetPost(
+				c.Context, req, docbasecli.OutputPostRendered(out, c.Int("lines"), style))
 		}
 		return docbasecli.GetPost(
 			c.Context, req, docbasecli.OutputPostBody(out))
 	},
 }
 
+var listPostsFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "query",
+		Aliases: []string{"q"},
+		Usage:   "`options` to narrow down the search. ex: groups,contributors, etc.",
+	},
+	&cli.IntFlag{
+		Name:    "page",
+		Aliases: []string{"p"},
+		Value:   1,
+		Usage:   "`num` of posts on a page",
+	},
+	&cli.IntFlag{
+		Name:    "per-page",
+		Aliases: []string{"pp"},
+		Value:   20,
+		Usage:   "`num` of page",
+	},
+	&cli.BoolFlag{
+		Name:    "meta",
+		Aliases: []string{"m"},
+		Usage:   "Display META-Fields (Total,Previous,Next) on footer",
+		Value:   false,
+	},
+}
+
 var listPosts = &cli.Command{
 	Name:  "list",
 	Usage: "Search and list posts on docbase.io",
-	Flags: []cli.Flag{
+	Description: `With --sort or filter options, every page of the search result is fetched
+and sorted or filtered locally (--page is ignored).`,
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "mine",
+			Usage: "List only posts written by configured UserID",
+		},
+		&cli.StringSliceFlag{
+			Name:    "group",
+			Aliases: []string{"g"},
+			Usage:   "`NAME` or ID of group to narrow down the search",
+		},
 		&cli.StringFlag{
-			Name:    "query",
-			Aliases: []string{"q"},
-			Usage:   "`options` to narrow down the search. ex: groups,contributors, etc.",
+			Name:  "sort",
+			Usage: "`KEY` to sort posts by: created, updated (newest first), title, stars or comments (most first)",
 		},
-		&cli.IntFlag{
-			Name:    "page",
-			Aliases: []string{"p"},
-			Value:   1,
-			Usage:   "`num` of posts on a page",
+		&cli.BoolFlag{
+			Name:  "reverse",
+			Usage: "Reverse the sort order",
 		},
 		&cli.IntFlag{
-			Name:    "per-page",
-			Aliases: []string{"pp"},
-			Value:   20,
-			Usage:   "`num` of page",
+			Name:  "min-length",
+			Usage: "List only posts whose body has at least `NUM` characters",
 		},
 		&cli.BoolFlag{
-			Name:    "meta",
-			Aliases: []string{"m"},
-			Usage:   "Display META-Fields (Total,Previous,Next) on footer",
-			Value:   false,
+			Name:  "no-tags",
+			Usage: "List only posts without tags",
 		},
-	},
+		&cli.StringFlag{
+			Name:  "updated-before",
+			Usage: "List only posts not updated for `PERIOD` (e.g. 90d, 12w, 1y) or since DATE (e.g. 2021-01-31)",
+		},
+		&cli.BoolFlag{
+			Name:  "has-attachments",
+			Usage: "List only posts with attached files",
+		},
+	}, listPostsFlags...),
 	Action: func(c *cli.Context) error {
 		if c.Bool("verbose") {
 			log.SetOutput(os.Stderr)
 		}
-		req := docbasecli.ListPostsRequest{Domain: c.String("domain")}
-		if c.String("query") != "" {
-			req.Query = pointer.StringPtr(c.String("query"))
+		req, err := buildListPostsRequest(c)
+		if err != nil {
+			return err
+		}
+		middlewares, err := listMiddlewares(c)
+		if err != nil {
+			return err
 		}
-		if c.Int("page") != 0 {
-			req.Page = pointer.IntPtr(c.Int("page"))
+		// 並べ替え・絞り込みは全ページを取得してから行うため、ページの指定とは併用できない
+		if len(middlewares) > 0 && (c.IsSet("page") || c.IsSet("per-page")) {
+			return errors.New("--page and --per-page cannot be used with --sort or filter flags")
 		}
-		if c.Int("per-page") != 0 {
-			req.PerPage = pointer.IntPtr(c.Int("per-page"))
+		for _, name := range c.StringSlice("group") {
+			g, err := docbasecli.ResolveGroup(c.Context, c.String("domain"), name)
+			if err != nil {
+				return err
+			}
+			req.Query = pointer.StringPtr(withGroupQuery(req.Query, g.Name))
 		}
 		presenter, err := docbasecli.BuildPostCollectionHandler(c.Bool("meta"))
 		if err != nil {
 			return err
 		}
-		return docbasecli.ListPosts(c.Context, req, presenter)
+		if len(middlewares) == 0 {
+			return docbasecli.ListPosts(c.Context, req, presenter)
+		}
+		posts, err := docbasecli.CollectPosts(c.Context, req)
+		if err != nil {
+			return err
+		}
+		handle := docbasecli.Compose(presenter, middlewares...)
+		return handle(c.Context, posts, docbase.Meta{Total: len(posts)})
 	},
 }
 
-// TODO(micheam): 設定ファイルで指定可能にする
-var defaultTitle = func() string {
-	now := time.Now()
-	return fmt.Sprintf("%s 作業メモ", now.Format("2006-01-02"))
+// listMiddlewares は、list の並べ替えと絞り込みのフラグから PostMiddleware を組み立てる
+func listMiddlewares(c *cli.Context) ([]docbasecli.PostMiddleware, error) {
+	filter := docbasecli.PostFilter{
+		MinLength:      c.Int("min-length"),
+		NoTags:         c.Bool("no-tags"),
+		HasAttachments: c.Bool("has-attachments"),
+	}
+	if s := c.String("updated-before"); s != "" {
+		t, err := docbasecli.ParseBefore(s, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		filter.UpdatedBefore = t
+	}
+	var middlewares []docbasecli.PostMiddleware
+	if !filter.IsZero() {
+		middlewares = append(middlewares, docbasecli.Filter(filter.Match))
+	}
+	if s := c.String("sort"); s != "" {
+		key, err := docbasecli.ParsePostSortKey(s)
+		if err != nil {
+			return nil, err
+		}
+		middlewares = append(middlewares, docbasecli.SortPosts(key, c.Bool("reverse")))
+	} else if c.Bool("reverse") {
+		return nil, errors.New("--reverse requires --sort")
+	}
+	return middlewares, nil
+}
+
+// buildListPostsRequest は、検索系のフラグから ListPostsRequest を組み立てる。
+// クエリ中の author:me は設定ファイルの UserID に展開される。
+func buildListPostsRequest(c *cli.Context) (docbasecli.ListPostsRequest, error) {
+	req := docbasecli.ListPostsRequest{Domain: c.String("domain")}
+	query := c.String("query")
+	if c.Bool("mine") {
+		query = strings.TrimSpace(query + " author:me")
+	}
+	if query != "" {
+		q, err := docbasecli.ExpandQuery(query, profile(c).UserID)
+		if err != nil {
+			return req, err
+		}
+		req.Query = pointer.StringPtr(q)
+	}
+	if c.Int("page") != 0 {
+		req.Page = pointer.IntPtr(c.Int("page"))
+	}
+	if c.Int("per-page") != 0 {
+		req.PerPage = pointer.IntPtr(c.Int("per-page"))
+	}
+	return req, nil
+}
+
+// defaultTitle は、プロファイルの DefaultTitle を展開したタイトルを返す
+func defaultTitle(c *cli.Context) (string, error) {
+	tmpl := profile(c).DefaultTitle
+	if tmpl == "" {
+		tmpl = docbasecli.DefaultTitleTemplate
+	}
+	return docbasecli.RenderTemplateString("title", tmpl, templateData(c))
+}
+
+func templateData(c *cli.Context) docbasecli.TemplateData {
+	return docbasecli.NewTemplateData(time.Now(), profile(c).UserID)
+}
+
+// postOptionFromTemplate は、展開済みのテンプレート t の設定を DefaultPostOption に適用する。
+// groups (グループ名もしくは ID) が指定された場合は、テンプレートのグループに追加される。
+func postOptionFromTemplate(c *cli.Context, t *docbasecli.PostTemplate, groups []string) (*docbase.PostOption, error) {
+	opt := docbasecli.DefaultPostOption
+	if len(t.Tags) != 0 {
+		opt.Tags = t.Tags
+	}
+	if t.Scope != "" {
+		opt.Scope = t.Scope
+	}
+	if t.Draft != nil {
+		opt.Draft = t.Draft
+	}
+	groups = append(append([]string{}, t.Groups...), groups...)
+	if len(groups) != 0 {
+		ids, err := docbasecli.ResolveGroupIDs(c.Context, c.String("domain"), groups)
+		if err != nil {
+			return nil, err
+		}
+		opt.Scope = string(docbase.ScopeGroup)
+		opt.Groups = ids
+	}
+	return &opt, nil
 }
 
 var newPost = &cli.Command{
@@ -180,12 +345,10 @@ var newPost = &cli.Command{
 		// TODO(micheam): option `--notice`
 		// TODO(micheam): option `--tags`
 		// TODO(micheam): option `--scope`
-		// TODO(micheam): option `--groups`
 		&cli.StringFlag{
 			Name:    "title",
 			Aliases: []string{"t"},
-			Usage:   "`STR-VAL` for title",
-			Value:   defaultTitle(),
+			Usage:   "`STR-VAL` for title (default: DefaultTitle of profile)",
 		},
 		&cli.StringFlag{
 			Name:    "body",
@@ -196,6 +359,17 @@ var newPost = &cli.Command{
 			Name:  "body-file",
 			Usage: "`PATH` of input file",
 		},
+		&cli.StringSliceFlag{
+			Name:    "group",
+			Aliases: []string{"g"},
+			Usage:   "`NAME` or ID of group to publish the post to",
+		},
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "`NAME` of template to create the post from",
+		},
+		lintFlag,
+		allowSecretsFlag,
 	},
 	Action: func(c *cli.Context) error {
 		if c.Bool("verbose") {
@@ -206,159 +380,65 @@ var newPost = &cli.Command{
 			Domain: c.String("domain"),
 		}
 
-		// Body
-		if len(c.String("body")) != 0 {
-			req.Body = strings.NewReader(c.String("body"))
-		} else if len(c.String("body-file")) != 0 {
-			filepath := c.String("body-file")
-			file, err := os.Open(filepath)
+		// Template
+		rendered := new(docbasecli.PostTemplate)
+		if name := c.String("template"); name != "" {
+			t, err := docbasecli.LoadTemplate(name)
 			if err != nil {
-				return fmt.Errorf("cant open %q: %w", filepath, err)
+				return err
 			}
-			defer func() { _ = file.Close() }()
-			req.Body = file
-		} else {
-			tempfile, err := ioutil.TempFile(os.TempDir(), "*.md")
-			if err != nil {
+			if rendered, err = t.Render(templateData(c)); err != nil {
 				return err
 			}
-			defer func() { _ = os.Remove(tempfile.Name()) }()
-			b, err := docbasecli.CaptureInputFromEditor(
-				docbasecli.GetPreferredEditorFromEnvironment,
-				tempfile,
-			)
-			if err != nil {
-				return fmt.Errorf("faild to capture input: %w", err)
+			if req.Title == "" {
+				req.Title = rendered.Title
 			}
-			req.Body = bytes.NewReader(b)
 		}
+		initial := rendered.Body
 
-		presenter := func(ctx context.Context, post docbase.Post) error {
-			fmt.Println(post.URL)
-			return nil
+		// Title
+		if req.Title == "" {
+			title, err := defaultTitle(c)
+			if err != nil {
+				return err
+			}
+			req.Title = title
 		}
-		return docbasecli.CreatePost(c.Context, req, presenter)
-	},
-}
 
-var editPost = &cli.Command{
-	Name:      "edit",
-	Usage:     "edit specified post.",
-	ArgsUsage: "ID",
-	Flags: []cli.Flag{
-		// TODO(micheam): option `--dradt`
-		// TODO(micheam): option `--notice`
-		// TODO(micheam): option `--tags`
-		// TODO(micheam): option `--scope`
-		// TODO(micheam): option `--groups`
-		&cli.StringFlag{
-			Name:    "title",
-			Aliases: []string{"t"},
-			Usage:   "`STR-VAL` for title",
-			Value:   defaultTitle(),
-		},
-		&cli.StringFlag{
-			Name:    "body",
-			Aliases: []string{"b"},
-			Usage:   "`STR-VAL` for body",
-		},
-		&cli.StringFlag{
-			Name:  "body-file",
-			Usage: "`PATH` of input file",
-		},
-	},
-	Action: func(c *cli.Context) error {
-		if c.Bool("verbose") {
-			log.SetOutput(os.Stderr)
-		}
-		if !c.Args().Present() {
-			return errors.New("need to specify target post id")
-		}
-		id, err := docbase.ParsePostID(c.Args().First())
+		// Option
+		opt, err := postOptionFromTemplate(c, rendered, c.StringSlice("group"))
 		if err != nil {
-			return fmt.Errorf("illegal post id: %w", err)
-		}
-		req := docbasecli.UpdatePostRequest{
-			Domain: c.String("domain"),
-			ID:     id,
-		}
-
-		// Get existing post
-		var existing docbase.Post
-		{
-			r := docbasecli.GetPostRequest{
-				Domain: c.String("domain"),
-				ID:     id,
-			}
-			var h = func(_ context.Context, post docbase.Post) error {
-				existing = post
-				return nil
-			}
-			err := docbasecli.GetPost(c.Context, r, h)
-			if err != nil {
-				return fmt.Errorf("faild to get existing post(%d): %w", id, err)
-			}
+			return err
 		}
+		req.Option = opt
 
 		// Body
+		var body string
 		if len(c.String("body")) != 0 {
-			req.Body = strings.NewReader(c.String("body"))
+			body = c.String("body")
 		} else if len(c.String("body-file")) != 0 {
 			filepath := c.String("body-file")
-			file, err := os.Open(filepath)
+			b, err := ioutil.ReadFile(filepath)
 			if err != nil {
 				return fmt.Errorf("cant open %q: %w", filepath, err)
 			}
-			defer func() { _ = file.Close() }()
-			req.Body = file
+			body = string(b)
 		} else {
-			// TODO(micheam): Cut it out to a function and test it
-			dir := os.Getenv("DOCBASE_TEMP_DIR")
-			tempfile, err := ioutil.TempFile(dir, fmt.Sprintf("%010d.*.md", id))
+			b, err := captureFromEditor(os.TempDir(), "*.md", initial)
 			if err != nil {
 				return err
 			}
-			defer func() { _ = os.Remove(tempfile.Name()) }()
-			i, err := tempfile.Write([]byte(existing.Body))
-			if err != nil {
-				return err
-			}
-			log.Printf("write %d bytes of default value", i)
-			b, err := docbasecli.CaptureInputFromEditor(
-				docbasecli.GetPreferredEditorFromEnvironment,
-				tempfile,
-			)
-			if err != nil {
-				return fmt.Errorf("faild to capture input: %w", err)
-			}
-			req.Body = bytes.NewReader(b)
+			body = string(b)
 		}
-		h := func(ctx context.Context, post docbase.Post) error {
-			fmt.Println("Updated.")
-			fmt.Println(post.URL)
-			return nil
+		if body, err = lintBeforeUpload(c, body, opt.Tags); err != nil {
+			return err
 		}
-		return docbasecli.UpatePost(c.Context, req, h)
-	},
-}
 
-var tags = &cli.Command{
-	Name:  "tags",
-	Usage: "Show tags of group",
-	Flags: []cli.Flag{},
-	Action: func(c *cli.Context) error {
-		if c.Bool("verbose") {
-			log.SetOutput(os.Stderr)
-		}
-		req := docbasecli.ListTagsRequest{
-			Domain: c.String("domain"),
-		}
-		presenter := func(ctx context.Context, tags []docbase.Tag) error {
-			for _, tag := range tags {
-				fmt.Println(tag.Name)
-			}
-			return nil
-		}
-		return docbasecli.ListTags(c.Context, req, presenter)
+		return uploadWithScan(c, func(scanner *docbasecli.SecretScanner) error {
+			req.Body = strings.NewReader(body)
+			req.Scanner = scanner
+			req.Hooks = profile(c).Hooks
+			return docbasecli.CreatePost(c.Context, req, docbasecli.PrintURL(os.Stdout, ""))
+		})
 	},
 }
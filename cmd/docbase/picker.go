@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/docbase-cli/pointer"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+// pickerPerPage 選択画面で１回の検索ごとに取得するメモの件数
+const pickerPerPage = 50
+
+// postIDArgs は、引数で指定されたメモの ID を返す。
+// 引数が省略され標準出力が端末の場合は、メモを対話的に選択させる。
+func postIDArgs(c *cli.Context, multi bool) ([]docbase.PostID, error) {
+	if c.Args().Present() || !docbasecli.IsTerminal(os.Stdout) {
+		return parsePostIDs(c.Args().Slice())
+	}
+	posts, err := pickPosts(c, multi)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]docbase.PostID, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	return ids, nil
+}
+
+// postIDArg は、postIDArgs と同様に単一のメモの ID を返す
+func postIDArg(c *cli.Context) (docbase.PostID, error) {
+	ids, err := postIDArgs(c, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) > 1 {
+		return 0, fmt.Errorf("too many post ids: %s", joinPostIDs(ids))
+	}
+	return ids[0], nil
+}
+
+func pickPosts(c *cli.Context, multi bool) ([]docbase.Post, error) {
+	var (
+		domain = c.String("domain")
+		userID = profile(c).UserID
+	)
+	source := func(ctx context.Context, query string) ([]docbase.Post, error) {
+		query, err := docbasecli.ExpandQuery(query, userID)
+		if err != nil {
+			return nil, err
+		}
+		req := docbasecli.ListPostsRequest{
+			Domain:  domain,
+			Query:   &query,
+			PerPage: pointer.IntPtr(pickerPerPage),
+		}
+		var posts []docbase.Post
+		err = docbasecli.ListPosts(ctx, req, func(_ context.Context, ps []docbase.Post, _ docbase.Meta) error {
+			posts = ps
+			return nil
+		})
+		return posts, err
+	}
+	req := docbasecli.PickPostsRequest{
+		Source:  source,
+		Multi:   multi,
+		Command: profile(c).Picker,
+	}
+	return docbasecli.PickPosts(c.Context, req)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+var render = &cli.Command{
+	Name:      "render",
+	Usage:     "Render posts to standalone HTML or PDF",
+	ArgsUsage: "[ID...]",
+	Description: `Multiple posts are concatenated into one document with a table of contents.
+Images are downloaded and embedded, so the document can be shared
+with people who have no DocBase account.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "`FORMAT` to render: html or pdf",
+			Value: string(docbasecli.RenderHTML),
+		},
+		&cli.StringFlag{
+			Name:    "out",
+			Aliases: []string{"o"},
+			Usage:   "`DIR` to write the document",
+			Value:   ".",
+		},
+		&cli.StringFlag{
+			Name:  "name",
+			Usage: "file `NAME` without extension. defaults to the post ids",
+		},
+		&cli.StringFlag{
+			Name:  "title",
+			Usage: "`TITLE` of the document concatenating multiple posts",
+		},
+		&cli.StringFlag{
+			Name:  "css",
+			Usage: "stylesheet `FILE` embedded in HTML instead of the default theme",
+		},
+		&cli.StringFlag{
+			Name:  "font",
+			Usage: "TrueType font `FILE` for PDF. required to render non-Latin text",
+		},
+		&cli.BoolFlag{
+			Name:  "embed",
+			Usage: "Embed images in the document",
+			Value: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		format, err := docbasecli.ParseRenderFormat(c.String("to"))
+		if err != nil {
+			return err
+		}
+		ids, err := postIDArgs(c, true)
+		if err != nil {
+			return err
+		}
+		req := docbasecli.RenderRequest{
+			Domain: c.String("domain"),
+			Format: format,
+			Title:  c.String("title"),
+			Font:   c.String("font"),
+			Embed:  c.Bool("embed"),
+		}
+		if path := c.String("css"); path != "" {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			req.CSS = string(b)
+		}
+		for _, id := range ids {
+			post, err := getPost(c.Context, req.Domain, id)
+			if err != nil {
+				return fmt.Errorf("failed to get post(%d): %w", id, err)
+			}
+			req.Posts = append(req.Posts, *post)
+		}
+
+		name := c.String("name")
+		if name == "" {
+			name = renderName(ids)
+		}
+		if err := os.MkdirAll(c.String("out"), 0o755); err != nil {
+			return err
+		}
+		path := filepath.Join(c.String("out"), name+"."+string(format))
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := docbasecli.Render(c.Context, f, req); err != nil {
+			_ = f.Close()
+			_ = os.Remove(path)
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+// renderName は、書き出すファイルの名前を ID をつなげて作る
+func renderName(ids []docbase.PostID) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return strings.Join(s, "_")
+}
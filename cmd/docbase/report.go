@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var report = &cli.Command{
+	Name:  "report",
+	Usage: "Generate reports on posts",
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "stale",
+			Usage: "Report posts nobody has updated for a long time",
+			Description: `Outputs a Markdown report grouped by author and tag.
+Specify --publish to post the report itself to DocBase (as a private draft).`,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "older-than",
+					Usage: "`PERIOD` (e.g. 365d, 12w, 1y) or DATE (e.g. 2021-01-31) since which posts are not updated",
+					Value: "365d",
+				},
+				&cli.StringSliceFlag{
+					Name:  "tag",
+					Usage: "`TAG` to narrow down the posts",
+				},
+				&cli.StringSliceFlag{
+					Name:    "group",
+					Aliases: []string{"g"},
+					Usage:   "`NAME` or ID of group to narrow down the posts",
+				},
+				&cli.StringFlag{
+					Name:    "query",
+					Aliases: []string{"q"},
+					Usage:   "additional `QUERY` to narrow down the posts",
+				},
+				&cli.BoolFlag{
+					Name:  "publish",
+					Usage: "Post the report to DocBase",
+				},
+				&cli.StringFlag{
+					Name:    "title",
+					Aliases: []string{"t"},
+					Usage:   "`TITLE` of the published report (default: \"Stale posts YYYY-MM-DD\")",
+				},
+				formatFlag,
+				allowSecretsFlag,
+			},
+			Action: func(c *cli.Context) error {
+				format, err := docbasecli.ParseFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				now := time.Now()
+				before, err := docbasecli.ParseBefore(c.String("older-than"), now)
+				if err != nil {
+					return err
+				}
+				terms := []string{c.String("query")}
+				for _, tag := range c.StringSlice("tag") {
+					terms = append(terms, "tag:"+docbasecli.QuoteQuery(tag))
+				}
+				for _, name := range c.StringSlice("group") {
+					g, err := docbasecli.ResolveGroup(c.Context, c.String("domain"), name)
+					if err != nil {
+						return err
+					}
+					terms = append(terms, "group:"+docbasecli.QuoteQuery(g.Name))
+				}
+				query, err := docbasecli.ExpandQuery(strings.TrimSpace(strings.Join(terms, " ")), profile(c).UserID)
+				if err != nil {
+					return err
+				}
+				req := docbasecli.StaleReportRequest{Domain: c.String("domain"), Query: query, Before: before}
+				if !c.Bool("publish") {
+					return docbasecli.ReportStale(c.Context, req, docbasecli.OutputStaleReport(os.Stdout, format))
+				}
+				return docbasecli.ReportStale(c.Context, req, func(ctx context.Context, r docbasecli.StaleReport) error {
+					title := c.String("title")
+					if title == "" {
+						title = fmt.Sprintf("Stale posts %s", now.Format("2006-01-02"))
+					}
+					body := r.Markdown()
+					return uploadWithScan(c, func(scanner *docbasecli.SecretScanner) error {
+						req := docbasecli.CreatePostRequest{
+							Domain:  c.String("domain"),
+							Title:   title,
+							Body:    strings.NewReader(body),
+							Scanner: scanner,
+							Hooks:   profile(c).Hooks,
+						}
+						return docbasecli.CreatePost(ctx, req, docbasecli.PrintURL(os.Stdout, "Created."))
+					})
+				})
+			},
+		},
+	},
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var allowSecretsFlag = &cli.BoolFlag{
+	Name:  "allow-secrets",
+	Usage: "Upload even if the body seems to contain secrets or personal information",
+}
+
+var scan = &cli.Command{
+	Name:  "scan",
+	Usage: "Find secrets and personal information in posts",
+	Description: `Detectors, custom patterns and allowlist are configured in [PROFILE.Secrets] of config file.
+Exits with status 1 when anything is found.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "query",
+			Aliases: []string{"q"},
+			Usage:   "`QUERY` to select posts to scan",
+		},
+		formatFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		format, err := docbasecli.ParseFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+		query, err := docbasecli.ExpandQuery(c.String("query"), profile(c).UserID)
+		if err != nil {
+			return err
+		}
+		scanner, err := docbasecli.NewSecretScanner(profile(c).Secrets)
+		if err != nil {
+			return err
+		}
+		req := docbasecli.ScanPostsRequest{Domain: c.String("domain"), Query: query, Scanner: scanner}
+		var found bool
+		output := docbasecli.OutputSecretReports(os.Stdout, format)
+		err = docbasecli.ScanPosts(c.Context, req, func(ctx context.Context, reports []docbasecli.SecretReport) error {
+			found = len(reports) > 0
+			return output(ctx, reports)
+		})
+		if err != nil {
+			return err
+		}
+		if found {
+			return cli.Exit("", 1)
+		}
+		return nil
+	},
+}
+
+// uploadWithScan は、本文を検査する scanner を渡して upload を実行する。
+// 秘密情報が見つかった場合は、端末から確認できればそのままアップロードする。
+// (フックを重ねて実行しないよう、upload はやり直さない)
+// --allow-secrets が指定された場合は検査しない。
+func uploadWithScan(c *cli.Context, upload func(scanner *docbasecli.SecretScanner) error) error {
+	if c.Bool("allow-secrets") {
+		return upload(nil)
+	}
+	scanner, err := docbasecli.NewSecretScanner(profile(c).Secrets)
+	if err != nil {
+		return err
+	}
+	scanner.Confirm = confirmSecrets
+	return upload(scanner)
+}
+
+// confirmSecrets は、見つかった秘密情報を表示し、アップロードを続けるか端末で確認する
+func confirmSecrets(err *docbasecli.SecretError) error {
+	for _, f := range err.Findings {
+		fmt.Fprintf(os.Stderr, "secret:%s\n", f)
+	}
+	if !docbasecli.IsTerminal(os.Stdin) {
+		return fmt.Errorf("%w (use --allow-secrets to upload anyway)", err)
+	}
+	answer, aerr := docbasecli.Ask(os.Stdin, os.Stderr, "Upload anyway? [y/N]")
+	if aerr != nil {
+		return aerr
+	}
+	if answer != "y" && answer != "yes" {
+		return errors.New("canceled")
+	}
+	return nil
+}
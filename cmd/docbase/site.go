@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var site = &cli.Command{
+	Name:  "site",
+	Usage: "Generate static site from posts",
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "build",
+			Usage: "Build read-only site of posts matching a search query",
+			Description: `Links between posts in the site are rewritten to local pages.
+Only pages of posts updated since the last build are rewritten;
+specify --full to rebuild all pages.`,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "query",
+					Aliases:  []string{"q"},
+					Usage:    "`QUERY` to select posts",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    "out",
+					Aliases: []string{"o"},
+					Usage:   "`DIR` to write the site",
+					Value:   "public",
+				},
+				&cli.StringFlag{
+					Name:  "title",
+					Usage: "`TITLE` of the site. defaults to the query",
+				},
+				&cli.StringFlag{
+					Name:  "css",
+					Usage: "stylesheet `FILE` instead of the default theme",
+				},
+				&cli.BoolFlag{
+					Name:  "embed",
+					Usage: "Embed images in the pages",
+					Value: true,
+				},
+				&cli.BoolFlag{
+					Name:  "full",
+					Usage: "Rebuild all pages ignoring the last build",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				query, err := docbasecli.ExpandQuery(c.String("query"), profile(c).UserID)
+				if err != nil {
+					return err
+				}
+				req := docbasecli.SiteBuildRequest{
+					Domain: c.String("domain"),
+					Query:  query,
+					Out:    c.String("out"),
+					Title:  c.String("title"),
+					Embed:  c.Bool("embed"),
+					Full:   c.Bool("full"),
+				}
+				if path := c.String("css"); path != "" {
+					b, err := ioutil.ReadFile(path)
+					if err != nil {
+						return err
+					}
+					req.CSS = string(b)
+				}
+				return docbasecli.BuildSite(c.Context, req, func(_ context.Context, r docbasecli.SiteBuildResult) error {
+					fmt.Printf("Built %d posts in %s (updated %d, removed %d).\n",
+						r.Posts, r.Out, len(r.Updated), len(r.Removed))
+					return nil
+				})
+			},
+		},
+	},
+}
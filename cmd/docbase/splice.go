@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/micheam/docbase-cli/text"
+	"github.com/urfave/cli/v2"
+)
+
+// isSplice は、エディタを使わずに本文の一部を書き換えるフラグが指定されているかを返す
+func isSplice(c *cli.Context) bool {
+	return c.IsSet("append") || c.IsSet("prepend") || c.IsSet("replace-with") || c.Bool("from-stdin")
+}
+
+// checkSpliceFlags は、同時に指定できない書き換えのフラグの組み合わせを拒否する
+func checkSpliceFlags(c *cli.Context) error {
+	var modes []string
+	for _, name := range []string{"append", "prepend", "replace-with"} {
+		if c.IsSet(name) {
+			modes = append(modes, "--"+name)
+		}
+	}
+	if len(modes) > 1 {
+		return fmt.Errorf("%s cannot be used together", strings.Join(modes, " and "))
+	}
+	if !c.Bool("from-stdin") {
+		return nil
+	}
+	switch {
+	case c.IsSet("replace-with"):
+		return errors.New("--from-stdin cannot be used with --replace-with")
+	case c.String("append") != "" || c.String("prepend") != "":
+		return errors.New("--from-stdin cannot be used with the text of --append/--prepend")
+	}
+	return nil
+}
+
+// spliceBody は、 --append/--prepend/--section/--replace-with に従って body を書き換える
+func spliceBody(c *cli.Context, body string) (string, error) {
+	var (
+		section = c.String("section")
+		content string
+	)
+	switch {
+	case c.Bool("from-stdin"):
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		content = string(b)
+	case c.IsSet("replace-with"):
+		b, err := ioutil.ReadFile(c.String("replace-with"))
+		if err != nil {
+			return "", fmt.Errorf("cant read %q: %w", c.String("replace-with"), err)
+		}
+		content = string(b)
+	case c.IsSet("prepend"):
+		content = c.String("prepend")
+	default:
+		content = c.String("append")
+	}
+	content = text.Dos2Unix(content)
+
+	var (
+		spliced string
+		err     error
+	)
+	switch {
+	case c.IsSet("replace-with"):
+		if section == "" {
+			return "", errors.New("--replace-with requires --section")
+		}
+		spliced, err = text.ReplaceSection(body, section, content)
+	case c.IsSet("prepend") && section != "":
+		spliced, err = text.PrependToSection(body, section, content)
+	case c.IsSet("prepend"):
+		spliced = text.PrependText(body, content)
+	case section != "":
+		spliced, err = text.AppendToSection(body, section, content)
+	default:
+		spliced = text.AppendText(body, content)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", err, section)
+	}
+	return spliced, nil
+}
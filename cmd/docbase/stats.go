@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var stats = &cli.Command{
+	Name:  "stats",
+	Usage: "Show activity statistics of the team",
+	Description: `Counts posts created or updated in [--since, --until) per user, tag and group,
+and lists the most commented and starred posts.
+Defaults to the previous month.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "`DATE` (e.g. 2021-04-01) or PERIOD ago (e.g. 30d) to start from",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "`DATE` (exclusive) or PERIOD ago to end at",
+		},
+		&cli.StringFlag{
+			Name:    "query",
+			Aliases: []string{"q"},
+			Usage:   "`QUERY` to narrow down the posts",
+		},
+		&cli.IntFlag{
+			Name:  "top",
+			Usage: "`NUM` of most commented and starred posts to show",
+			Value: docbasecli.DefaultStatsTop,
+		},
+		formatFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		format, err := docbasecli.ParseFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		since, until := thisMonth.AddDate(0, -1, 0), thisMonth
+		if s := c.String("since"); s != "" {
+			if since, err = docbasecli.ParseBefore(s, now); err != nil {
+				return err
+			}
+		}
+		if s := c.String("until"); s != "" {
+			if until, err = docbasecli.ParseBefore(s, now); err != nil {
+				return err
+			}
+		} else if c.IsSet("since") {
+			until = now
+		}
+		if !since.Before(until) {
+			return errors.New("--since must be before --until")
+		}
+		query, err := docbasecli.ExpandQuery(c.String("query"), profile(c).UserID)
+		if err != nil {
+			return err
+		}
+		req := docbasecli.ActivityStatsRequest{
+			Domain: c.String("domain"),
+			Query:  query,
+			Since:  since,
+			Until:  until,
+			Top:    c.Int("top"),
+		}
+		return docbasecli.Stats(c.Context, req, docbasecli.OutputActivityStats(os.Stdout, format))
+	},
+}
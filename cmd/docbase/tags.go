@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var formatFlag = &cli.StringFlag{
+	Name:    "format",
+	Aliases: []string{"f"},
+	Usage:   "output `FORMAT` (text, json or csv)",
+	Value:   string(docbasecli.FormatText),
+}
+
+var tags = &cli.Command{
+	Name:  "tags",
+	Usage: "Show tags of group",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "count",
+			Aliases: []string{"c"},
+			Usage:   "Display number of posts for each tag",
+		},
+		formatFlag,
+	},
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Action: func(c *cli.Context) error {
+		format, err := docbasecli.ParseFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+		if c.Bool("count") {
+			req := docbasecli.ListTagStatsRequest{
+				Domain:   c.String("domain"),
+				MaxCount: -1,
+			}
+			return docbasecli.ListTagStats(c.Context, req, docbasecli.OutputTagStats(os.Stdout, format))
+		}
+		req := docbasecli.ListTagsRequest{
+			Domain: c.String("domain"),
+		}
+		return docbasecli.ListTags(c.Context, req, docbasecli.OutputTags(os.Stdout, format))
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:      "rename",
+			Usage:     "Rename a tag on every post",
+			ArgsUsage: "OLD NEW",
+			Flags:     append([]cli.Flag{journalFlag}, batchFlags...),
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 2 {
+					return errors.New("need to specify OLD and NEW tag name")
+				}
+				return runMergeTags(c, c.Args().Slice()[:1], c.Args().Get(1))
+			},
+		},
+		{
+			Name:      "merge",
+			Usage:     "Merge tags into one",
+			ArgsUsage: "A B... INTO C",
+			Flags:     append([]cli.Flag{journalFlag}, batchFlags...),
+			Action: func(c *cli.Context) error {
+				args := c.Args().Slice()
+				i := len(args) - 2
+				if i < 1 || !strings.EqualFold(args[i], "into") {
+					return errors.New("usage: tags merge A B... INTO C")
+				}
+				return runMergeTags(c, args[:i], args[i+1])
+			},
+		},
+		{
+			Name:  "unused",
+			Usage: "Show tags with few posts",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "max-count",
+					Usage: "show tags used by at most `NUM` posts",
+					Value: 1,
+				},
+				formatFlag,
+			},
+			Action: func(c *cli.Context) error {
+				format, err := docbasecli.ParseFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				req := docbasecli.ListTagStatsRequest{
+					Domain:   c.String("domain"),
+					MaxCount: c.Int("max-count"),
+				}
+				return docbasecli.ListTagStats(c.Context, req, docbasecli.OutputTagStats(os.Stdout, format))
+			},
+		},
+	},
+}
+
+func runMergeTags(c *cli.Context, from []string, into string) error {
+	j, err := docbasecli.PlanMergeTags(c.Context, docbasecli.MergeTagsRequest{
+		Domain: c.String("domain"),
+		From:   from,
+		Into:   into,
+	})
+	if err != nil {
+		return err
+	}
+	return applyJournal(c, j)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+// templateSkeleton は、 templates edit で新規作成されるテンプレートの初期内容
+const templateSkeleton = `---
+title: "{{.Date}} "
+tags: []
+---
+`
+
+var templates = &cli.Command{
+	Name:  "templates",
+	Usage: "Manage post templates",
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "List templates",
+			Action: func(c *cli.Context) error {
+				ts, err := docbasecli.ListTemplates()
+				if err != nil {
+					return err
+				}
+				for _, t := range ts {
+					fmt.Printf("%s\t%s\n", t.Name, t.Title)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "show",
+			Usage:     "Show template",
+			ArgsUsage: "NAME",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "render",
+					Aliases: []string{"r"},
+					Usage:   "Show rendered title, tags and body",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if !c.Args().Present() {
+					return errors.New("need to specify template name")
+				}
+				t, err := docbasecli.LoadTemplate(c.Args().First())
+				if err != nil {
+					return err
+				}
+				if !c.Bool("render") {
+					b, err := ioutil.ReadFile(t.Path)
+					if err != nil {
+						return err
+					}
+					_, err = os.Stdout.Write(b)
+					return err
+				}
+				rendered, err := t.Render(templateData(c))
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Title: %s\nTags:  %v\n---\n%s", rendered.Title, rendered.Tags, rendered.Body)
+				return nil
+			},
+		},
+		{
+			Name:      "edit",
+			Usage:     "Edit template (create if not exists)",
+			ArgsUsage: "NAME",
+			Action: func(c *cli.Context) error {
+				if !c.Args().Present() {
+					return errors.New("need to specify template name")
+				}
+				path, err := docbasecli.TemplatePath(c.Args().First())
+				if err != nil {
+					return err
+				}
+				if _, err := os.Stat(path); os.IsNotExist(err) {
+					if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+						return err
+					}
+					if err := ioutil.WriteFile(path, []byte(templateSkeleton), 0o644); err != nil {
+						return err
+					}
+				}
+				return docbasecli.OpenFileInEditor(path, docbasecli.GetPreferredEditorFromEnvironment)
+			},
+		},
+	},
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/go-docbase"
+	"github.com/urfave/cli/v2"
+)
+
+var tui = &cli.Command{
+	Name:  "tui",
+	Usage: "Browse posts in full-screen terminal UI",
+	Description: `Press Tab to switch between the tag/group sidebar and the post list,
+"/" to search, "o" to open in browser, "e" to edit, "a" to archive,
+"c" to comment and "q" to quit.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "query",
+			Aliases: []string{"q"},
+			Usage:   "initial search `QUERY`",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		// ログは画面を崩すため --verbose が指定されても出力しない
+		if !docbasecli.IsTerminal(os.Stdout) {
+			return errors.New("tui requires a terminal")
+		}
+		query, err := docbasecli.ExpandQuery(c.String("query"), profile(c).UserID)
+		if err != nil {
+			return err
+		}
+		req := docbasecli.TUIRequest{
+			Domain: c.String("domain"),
+			Query:  query,
+			Edit: func(_ context.Context, post docbase.Post) error {
+				return editPostInEditor(c, post)
+			},
+		}
+		return docbasecli.RunTUI(c.Context, req)
+	},
+}
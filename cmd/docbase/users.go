@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/micheam/docbase-cli/pointer"
+	"github.com/urfave/cli/v2"
+)
+
+var whoami = &cli.Command{
+	Name:  "whoami",
+	Usage: "Validate access token and show user, team and rate-limit status",
+	Flags: []cli.Flag{formatFlag},
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		format, err := docbasecli.ParseFormat(c.String("format"))
+		if err != nil {
+			return err
+		}
+		req := docbasecli.WhoamiRequest{
+			Domain: c.String("domain"),
+			UserID: profile(c).UserID,
+		}
+		return docbasecli.Whoami(c.Context, req, docbasecli.OutputIdentity(os.Stdout, format))
+	},
+}
+
+var users = &cli.Command{
+	Name:  "users",
+	Usage: "Show team members",
+	Before: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		return nil
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "List team members",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "query",
+					Aliases: []string{"q"},
+					Usage:   "`NAME` to narrow down users (partial match)",
+				},
+				formatFlag,
+			},
+			Action: func(c *cli.Context) error {
+				format, err := docbasecli.ParseFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				req := docbasecli.ListUsersRequest{Domain: c.String("domain")}
+				if c.String("query") != "" {
+					req.Query = pointer.StringPtr(c.String("query"))
+				}
+				return docbasecli.ListAllUsers(c.Context, req, docbasecli.OutputMembers(os.Stdout, format))
+			},
+		},
+		{
+			Name:      "show",
+			Usage:     "Show team member detail",
+			ArgsUsage: "USERNAME|ID",
+			Flags:     []cli.Flag{formatFlag},
+			Action: func(c *cli.Context) error {
+				format, err := docbasecli.ParseFormat(c.String("format"))
+				if err != nil {
+					return err
+				}
+				if !c.Args().Present() {
+					return errors.New("need to specify username or id")
+				}
+				u, err := docbasecli.ResolveUser(c.Context, c.String("domain"), c.Args().First())
+				if err != nil {
+					return err
+				}
+				return docbasecli.OutputMemberDetail(os.Stdout, format)(c.Context, *u)
+			},
+		},
+	},
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	docbasecli "github.com/micheam/docbase-cli"
+	"github.com/urfave/cli/v2"
+)
+
+var watch = &cli.Command{
+	Name:  "watch",
+	Usage: "Poll posts matching query and notify changes",
+	Description: `Outputs new, updated and archived posts as NDJSON (one JSON per line).
+Seen posts are recorded in a state file, so restarting does not report old changes.
+The first run only records the current posts.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "query",
+			Aliases:  []string{"q"},
+			Usage:    "`QUERY` of posts to watch",
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "`DURATION` between polls",
+			Value: docbasecli.DefaultWatchInterval,
+		},
+		&cli.StringFlag{
+			Name:  "state",
+			Usage: "`PATH` of the state file (default: in the cache directory)",
+		},
+		&cli.BoolFlag{
+			Name:  "once",
+			Usage: "Poll only once and exit (e.g. from cron)",
+		},
+		&cli.StringFlag{
+			Name:  "exec",
+			Usage: "`COMMAND` to run for each event (event JSON is given to stdin)",
+		},
+		&cli.StringFlag{
+			Name:  "webhook",
+			Usage: "`URL` to POST each event as JSON",
+		},
+		&cli.DurationFlag{
+			Name:  "webhook-timeout",
+			Usage: "`DURATION` to wait for the webhook per event",
+			Value: docbasecli.DefaultWebhookTimeout,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("verbose") {
+			log.SetOutput(os.Stderr)
+		}
+		query, err := docbasecli.ExpandQuery(c.String("query"), profile(c).UserID)
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+		defer stop()
+
+		output := docbasecli.OutputWatchEvent(os.Stdout)
+		var notifiers []docbasecli.WatchEventHandler
+		if command := c.String("exec"); command != "" {
+			notifiers = append(notifiers, docbasecli.ExecWatchEvent(command))
+		}
+		if url := c.String("webhook"); url != "" {
+			notifiers = append(notifiers, docbasecli.PostWatchEvent(url, c.Duration("webhook-timeout")))
+		}
+		req := docbasecli.WatchRequest{
+			Domain:    c.String("domain"),
+			Query:     query,
+			Interval:  c.Duration("interval"),
+			StatePath: c.String("state"),
+			Once:      c.Bool("once"),
+			OnError: func(err error) {
+				fmt.Fprintln(os.Stderr, err)
+			},
+		}
+		return docbasecli.Watch(ctx, req, func(ctx context.Context, e docbasecli.WatchEvent) error {
+			if err := output(ctx, e); err != nil {
+				return err
+			}
+			// 通知に失敗しても監視は続ける
+			for _, notify := range notifiers {
+				if err := notify(ctx, e); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			}
+			return nil
+		})
+	},
+}
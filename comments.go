@@ -0,0 +1,56 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/micheam/go-docbase"
+)
+
+// Comment は、メモに付けられたコメント
+type Comment struct {
+	ID        int          `json:"id"`
+	Body      string       `json:"body"`
+	CreatedAt string       `json:"created_at"` // ISO 8601
+	User      docbase.User `json:"user"`
+}
+
+type CommentHandler func(ctx context.Context, comment Comment) error
+
+/***************************************
+ * Create Comment
+ ***************************************/
+
+type CreateCommentRequest struct {
+	Domain string
+	ID     docbase.PostID
+	Body   string
+
+	// Notice 省略した場合はメモの作成者や参加者に通知する
+	Notice *bool
+}
+
+func CreateComment(ctx context.Context, req CreateCommentRequest, handle CommentHandler) error {
+	log.Printf("create comment with req: %v", req)
+	payload := map[string]interface{}{"body": req.Body}
+	if req.Notice != nil {
+		payload["notice"] = *req.Notice
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	r, err := newRequest(ctx, http.MethodPost, buildURL("teams", req.Domain, "posts", req.ID.String(), "comments"), bytes.NewReader(b), nil)
+	if err != nil {
+		return err
+	}
+	var comment Comment
+	if err := doRequest(r, &comment); err != nil {
+		return fmt.Errorf("failed to comment on post(%d): %w", req.ID, err)
+	}
+	return handle(ctx, comment)
+}
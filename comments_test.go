@@ -0,0 +1,41 @@
+package docbasecli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/docbase-cli/pointer"
+)
+
+func TestCreateComment(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/teams/example/posts/123/comments" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		var got map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{"body": "LGTM", "notice": false}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("payload mismatch (-want, +got):%s\n", diff)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1, "body": "LGTM", "created_at": "2026-10-19T15:04:00+09:00", "user": {"id": 2, "name": "micheam"}}`))
+	}))
+	req := CreateCommentRequest{Domain: "example", ID: 123, Body: "LGTM", Notice: pointer.BoolPtr(false)}
+	var got Comment
+	err := CreateComment(context.Background(), req, func(_ context.Context, c Comment) error {
+		got = c
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 1 || got.User.Name != "micheam" {
+		t.Errorf("unexpected comment: %+v", got)
+	}
+}
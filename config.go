@@ -2,9 +2,12 @@ package docbasecli
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 
+	"github.com/micheam/docbase-cli/text"
 	"github.com/pelletier/go-toml"
 )
 
@@ -15,11 +18,35 @@ type Config struct {
 	Domain      string
 	UserID      string
 	Editor      string
+
+	// DefaultTitle new で --title を省略した場合のタイトル (text/template)
+	// 省略した場合は DefaultTitleTemplate が使われる
+	DefaultTitle string
+
+	// Picker ID を省略した場合のメモの選択に使う fzf 互換のコマンド (e.g. "fzf --height 40%")
+	// 省略した場合は組み込みの選択画面が使われる
+	Picker string
+
+	// Lint lint コマンドや --lint で使う規則の設定
+	Lint text.LintConfig
+
+	// Secrets アップロード前や scan コマンドで使う秘密情報の検出の設定
+	Secrets SecretConfig
+
+	// Hooks メモの作成・更新・削除の前後に実行するコマンド (e.g. {"pre-create": "./check.sh"})
+	Hooks Hooks
 }
 
+// DefaultProfile 既定で読み込まれるプロファイル名
+const DefaultProfile = "default"
+
 // LoadConfig は、 Default 設定を読み込みます。
 func LoadConfig(r io.Reader) (*Config, error) {
-	const key = "default"
+	return LoadProfile(r, DefaultProfile)
+}
+
+// LoadProfile は、 name で指定されたプロファイルの設定を読み込みます。
+func LoadProfile(r io.Reader, name string) (*Config, error) {
 	var (
 		confMap = ConfigMap{}
 		err     error
@@ -33,9 +60,44 @@ func LoadConfig(r io.Reader) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	found, ok := confMap[key]
+	found, ok := confMap[name]
 	if !ok {
-		return nil, errors.New("'default' profile not found")
+		return nil, fmt.Errorf("'%s' profile not found", name)
 	}
 	return &found, nil
 }
+
+// ResolveAccessToken は、API の呼び出しに使うアクセストークンを返す。
+// token (--token もしくは環境変数 DOCBASE_TOKEN) が空の場合は、プロファイルの AccessToken を使う。
+func ResolveAccessToken(token string, conf *Config) string {
+	if token != "" || conf == nil {
+		return token
+	}
+	return conf.AccessToken
+}
+
+// ConfigDir は、設定ファイルやテンプレートを配置するディレクトリを返す。
+// 環境変数 DOCBASE_CONFIG_DIR で変更できる。 (既定: ~/.config/docbase)
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("DOCBASE_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "docbase"), nil
+}
+
+// CacheDir は、キャッシュや一括操作の記録などを保存するディレクトリを返す。
+// 環境変数 DOCBASE_CACHE_DIR で変更できる。
+func CacheDir() (string, error) {
+	if dir := os.Getenv("DOCBASE_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "docbase"), nil
+}
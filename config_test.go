@@ -2,9 +2,15 @@ package docbasecli
 
 import (
 	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/docbase-cli/text"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -34,3 +40,157 @@ UserID      = "user-id1"
 		t.Errorf("configMap mismatch (-want, +got):%s\n", diff)
 	}
 }
+
+func TestLoadProfile(t *testing.T) {
+	doc := []byte(`[default]
+Domain = "domain"
+[work]
+Domain = "work-domain"
+UserID = "work-user"
+`)
+	got, err := LoadProfile(bytes.NewReader(doc), "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{Domain: "work-domain", UserID: "work-user"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("config mismatch (-want, +got):%s\n", diff)
+	}
+	if _, err := LoadProfile(bytes.NewReader(doc), "missing"); err == nil {
+		t.Errorf("want error for missing profile")
+	}
+}
+
+func TestLoadProfile_Lint(t *testing.T) {
+	doc := []byte(`[default]
+Domain = "domain"
+[default.Lint]
+Disable = ["todo"]
+MaxLineLength = 120
+BannedWords = ["foo"]
+[default.Lint.RequiredSections]
+"日報" = ["やったこと", "明日やること"]
+`)
+	got, err := LoadConfig(bytes.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{Domain: "domain", Lint: text.LintConfig{
+		Disable:          []string{"todo"},
+		MaxLineLength:    120,
+		BannedWords:      []string{"foo"},
+		RequiredSections: map[string][]string{"日報": {"やったこと", "明日やること"}},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("config mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestLoadProfile_Secrets(t *testing.T) {
+	doc := []byte(`[default]
+Domain = "domain"
+[default.Secrets]
+Disable = ["phone"]
+Patterns = { "customer-id" = 'CUST-\d{6}' }
+Allowlist = "/path/to/allowlist.txt"
+`)
+	got, err := LoadConfig(bytes.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{Domain: "domain", Secrets: SecretConfig{
+		Disable:   []string{"phone"},
+		Patterns:  map[string]string{"customer-id": `CUST-\d{6}`},
+		Allowlist: "/path/to/allowlist.txt",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("config mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestLoadProfile_Hooks(t *testing.T) {
+	doc := []byte(`[default]
+Domain = "domain"
+[default.Hooks]
+pre-create = "./check.sh"
+post-update = "notify-slack"
+`)
+	got, err := LoadConfig(bytes.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{Domain: "domain", Hooks: Hooks{"pre-create": "./check.sh", "post-update": "notify-slack"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("config mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestResolveAccessToken_profile(t *testing.T) {
+	doc := `[default]
+AccessToken = "default-token"
+[work]
+AccessToken = "work-token"
+`
+	var got []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-DocBaseToken"))
+	}))
+	t.Cleanup(func() { SetAccessToken(os.Getenv("DOCBASE_TOKEN")) })
+
+	tests := []struct {
+		profile string
+		token   string
+	}{
+		{profile: "default"},
+		{profile: "work"},
+		{profile: "work", token: "flag-token"},
+	}
+	for _, tt := range tests {
+		conf, err := LoadProfile(strings.NewReader(doc), tt.profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		SetAccessToken(ResolveAccessToken(tt.token, conf))
+		r, err := newRequest(context.Background(), http.MethodGet, buildURL("teams", "example"), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := doRequest(r, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"default-token", "work-token", "flag-token"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("token mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-DocBaseToken"))
+	}))
+	defer ts.Close()
+	t.Cleanup(func() { SetAccessToken(os.Getenv("DOCBASE_TOKEN")) })
+	SetAccessToken("work-token")
+
+	// go-docbase は読み込み時のトークンをヘッダに付ける
+	api, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	api.Header.Add("X-DocBaseToken", "env-token")
+	webhook, _ := http.NewRequest(http.MethodPost, ts.URL, nil)
+	client := &http.Client{Transport: Transport{}}
+	for _, r := range []*http.Request{api, webhook} {
+		resp, err := client.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+	want := []string{"work-token", ""}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("token mismatch (-want, +got):%s\n", diff)
+	}
+	if http.DefaultClient.Transport != nil {
+		t.Errorf("http.DefaultClient must not be changed")
+	}
+}
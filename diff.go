@@ -0,0 +1,44 @@
+package docbasecli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+)
+
+// DiffContext 差分の前後に表示する行数
+const DiffContext = 3
+
+// DiffPost は、既存のメモ existing のタイトルと本文を title, body に
+// 変更した場合の差分を返す。title が nil の場合はタイトルを比較しない。
+// 差分がない場合は空文字を返す。
+func DiffPost(existing docbase.Post, title *string, body string, color bool) string {
+	sb := new(strings.Builder)
+	if title != nil && *title != existing.Title {
+		fmt.Fprintf(sb, "Title: %q -> %q\n", existing.Title, *title)
+	}
+	from := fmt.Sprintf("posts/%d", existing.ID)
+	diff := text.UnifiedDiff(text.Dos2Unix(existing.Body), text.Dos2Unix(body), from, from+" (edited)", DiffContext)
+	if color {
+		diff = text.ColorizeDiff(diff)
+	}
+	sb.WriteString(diff)
+	return sb.String()
+}
+
+// DiffRevisions は、リビジョン from から to への差分を返す
+func DiffRevisions(id docbase.PostID, from, to Revision, color bool) string {
+	sb := new(strings.Builder)
+	if from.Title != to.Title {
+		fmt.Fprintf(sb, "Title: %q -> %q\n", from.Title, to.Title)
+	}
+	diff := text.UnifiedDiff(text.Dos2Unix(from.Body), text.Dos2Unix(to.Body),
+		fmt.Sprintf("posts/%d@%d", id, from.Rev), fmt.Sprintf("posts/%d@%d", id, to.Rev), DiffContext)
+	if color {
+		diff = text.ColorizeDiff(diff)
+	}
+	sb.WriteString(diff)
+	return sb.String()
+}
@@ -7,7 +7,6 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"os/exec"
 	"runtime"
@@ -81,11 +80,10 @@ func CaptureInputFromEditor(resolveEditor PreferredEditorResolver, file *os.File
 }
 
 func OpenBrowser(_ context.Context, post docbase.Post) error {
-	openbrowser(post.URL)
-	return nil
+	return openbrowser(post.URL)
 }
 
-func openbrowser(url string) {
+func openbrowser(url string) error {
 	var err error
 	switch runtime.GOOS {
 	case "linux":
@@ -98,6 +96,7 @@ func openbrowser(url string) {
 		err = fmt.Errorf("unsupported platform")
 	}
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to open browser: %w", err)
 	}
+	return nil
 }
@@ -1,5 +1,23 @@
 package docbasecli
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 var ErrNotFound = errors.New("no post found")
+
+// APIError は DocBase API がエラーを返したことを表す
+type APIError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *APIError) Error() string {
+	return "docbase api returns NG: " + e.Status
+}
+
+// Is は 404 を ErrNotFound とみなす
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,125 @@
+package docbasecli
+
+// 取得したメモの並べ替えと絞り込み
+//
+// 検索 API の結果は API が返す順に並び、検索構文で表せない条件では絞り込めないため、
+// 取得した後に手元で処理する。
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/micheam/go-docbase"
+)
+
+// PostSortKey は、メモを並べ替える基準
+type PostSortKey string
+
+const (
+	SortCreated  PostSortKey = "created"  // 作成日時の新しい順
+	SortUpdated  PostSortKey = "updated"  // 更新日時の新しい順
+	SortTitle    PostSortKey = "title"    // タイトルの昇順
+	SortStars    PostSortKey = "stars"    // スターの多い順
+	SortComments PostSortKey = "comments" // コメントの多い順
+)
+
+// ParsePostSortKey は、文字列を PostSortKey に変換する
+func ParsePostSortKey(s string) (PostSortKey, error) {
+	switch k := PostSortKey(s); k {
+	case SortCreated, SortUpdated, SortTitle, SortStars, SortComments:
+		return k, nil
+	}
+	return "", fmt.Errorf("unsupported sort key %q (created, updated, title, stars or comments)", s)
+}
+
+// SortPosts は、メモを key の順に並べ替える。reverse の場合は逆順にする。
+func SortPosts(key PostSortKey, reverse bool) PostMiddleware {
+	var less func(a, b docbase.Post) bool
+	switch key {
+	case SortCreated:
+		less = func(a, b docbase.Post) bool { return postTime(a.CreatedAt).After(postTime(b.CreatedAt)) }
+	case SortUpdated:
+		less = func(a, b docbase.Post) bool { return postTime(a.UpdatedAt).After(postTime(b.UpdatedAt)) }
+	case SortTitle:
+		less = func(a, b docbase.Post) bool { return a.Title < b.Title }
+	case SortStars:
+		less = func(a, b docbase.Post) bool { return a.Stars > b.Stars }
+	case SortComments:
+		less = func(a, b docbase.Post) bool { return len(a.Comments) > len(b.Comments) }
+	default:
+		less = func(a, b docbase.Post) bool { return false }
+	}
+	if reverse {
+		forward := less
+		less = func(a, b docbase.Post) bool { return forward(b, a) }
+	}
+	return Sort(less)
+}
+
+// postTime は、ISO 8601 形式の日時を解析する。解析できない場合はゼロ値を返す。
+func postTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+// PostFilter は、取得したメモの絞り込みの条件。ゼロ値の項目は条件にしない。
+type PostFilter struct {
+	// MinLength 本文の最小の文字数
+	MinLength int
+	// NoTags タグの付いていないメモのみ
+	NoTags bool
+	// UpdatedBefore この日時より前に更新されたメモのみ
+	UpdatedBefore time.Time
+	// HasAttachments 添付ファイルを含むメモのみ
+	HasAttachments bool
+}
+
+// IsZero は、条件が何も指定されていないかを判定する
+func (f PostFilter) IsZero() bool {
+	return f.MinLength == 0 && !f.NoTags && f.UpdatedBefore.IsZero() && !f.HasAttachments
+}
+
+// Match は、post が条件をすべて満たすかを判定する
+func (f PostFilter) Match(post docbase.Post) bool {
+	if f.MinLength > 0 && utf8.RuneCountInString(post.Body) < f.MinLength {
+		return false
+	}
+	if f.NoTags && len(post.Tags) > 0 {
+		return false
+	}
+	if !f.UpdatedBefore.IsZero() && !postTime(post.UpdatedAt).Before(f.UpdatedBefore) {
+		return false
+	}
+	if f.HasAttachments && !strings.Contains(post.Body, "://"+attachmentHost+"/uploads/") {
+		return false
+	}
+	return true
+}
+
+// ParseBefore は、"90d" や "12w" のような now からの期間、もしくは "2021-01-31" のような日付を日時に変換する。
+// 期間は d (日), w (週), y (年) と time.ParseDuration の単位が使える。
+func ParseBefore(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if n := len(s); n > 1 {
+		if v, err := strconv.Atoi(s[:n-1]); err == nil && v >= 0 {
+			switch s[n-1] {
+			case 'd':
+				return now.AddDate(0, 0, -v), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*v), nil
+			case 'y':
+				return now.AddDate(-v, 0, 0), nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("illegal period or date %q (e.g. 90d, 12w, 1y, 2021-01-31)", s)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,103 @@
+package docbasecli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+var filterPosts = []docbase.Post{
+	{ID: 1, Title: "b", CreatedAt: "2021-01-01T00:00:00+09:00", UpdatedAt: "2021-03-01T00:00:00+09:00", Stars: 2,
+		Body: "![a](https://image.docbase.io/uploads/abc.png)", Tags: []docbase.Tag{{Name: "x"}}},
+	{ID: 2, Title: "a", CreatedAt: "2021-02-01T00:00:00+09:00", UpdatedAt: "2021-02-01T00:00:00+09:00", Stars: 5,
+		Body: "短い", Comments: []interface{}{"c1", "c2"}},
+	{ID: 3, Title: "c", CreatedAt: "2021-03-01T00:00:00+09:00", UpdatedAt: "2021-04-01T00:00:00+09:00",
+		Body: "long enough body", Comments: []interface{}{"c1"}},
+}
+
+func TestSortPosts(t *testing.T) {
+	tests := []struct {
+		key     PostSortKey
+		reverse bool
+		want    []docbase.PostID
+	}{
+		{SortCreated, false, []docbase.PostID{3, 2, 1}},
+		{SortCreated, true, []docbase.PostID{1, 2, 3}},
+		{SortUpdated, false, []docbase.PostID{3, 1, 2}},
+		{SortTitle, false, []docbase.PostID{2, 1, 3}},
+		{SortTitle, true, []docbase.PostID{3, 1, 2}},
+		{SortStars, false, []docbase.PostID{2, 1, 3}},
+		{SortComments, false, []docbase.PostID{2, 3, 1}},
+	}
+	for _, tt := range tests {
+		var got []docbase.PostID
+		handle := Compose(collect(&got), SortPosts(tt.key, tt.reverse))
+		if err := handle(context.Background(), filterPosts, docbase.Meta{}); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s (reverse=%v) mismatch (-want, +got):%s\n", tt.key, tt.reverse, diff)
+		}
+	}
+	if _, err := ParsePostSortKey("size"); err == nil {
+		t.Error("want error for unsupported key, but got nil")
+	}
+}
+
+func TestPostFilter_Match(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name   string
+		filter PostFilter
+		want   []docbase.PostID
+	}{
+		{"zero", PostFilter{}, []docbase.PostID{1, 2, 3}},
+		{"min length", PostFilter{MinLength: 3}, []docbase.PostID{1, 3}},
+		{"no tags", PostFilter{NoTags: true}, []docbase.PostID{2, 3}},
+		{"updated before", PostFilter{UpdatedBefore: time.Date(2021, 3, 15, 0, 0, 0, 0, jst)}, []docbase.PostID{1, 2}},
+		{"has attachments", PostFilter{HasAttachments: true}, []docbase.PostID{1}},
+		{"combined", PostFilter{NoTags: true, MinLength: 3}, []docbase.PostID{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []docbase.PostID
+			handle := Compose(collect(&got), Filter(tt.filter.Match))
+			if err := handle(context.Background(), filterPosts, docbase.Meta{}); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("filtered posts mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestParseBefore(t *testing.T) {
+	now := time.Date(2021, 4, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"90d", time.Date(2021, 1, 16, 12, 0, 0, 0, time.UTC), false},
+		{"2w", time.Date(2021, 4, 2, 12, 0, 0, 0, time.UTC), false},
+		{"1y", time.Date(2020, 4, 16, 12, 0, 0, 0, time.UTC), false},
+		{"36h", time.Date(2021, 4, 15, 0, 0, 0, 0, time.UTC), false},
+		{"2021-01-31", time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{"soon", time.Time{}, true},
+		{"-3d", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBefore(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: want error %v, but got %v", tt.in, tt.wantErr, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%q: want %v, but got %v", tt.in, tt.want, got)
+		}
+	}
+}
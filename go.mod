@@ -3,17 +3,18 @@ module github.com/micheam/docbase-cli
 go 1.17
 
 require (
+	github.com/go-pdf/fpdf v0.6.0
 	github.com/google/go-cmp v0.5.7
 	github.com/mattn/go-isatty v0.0.14
 	github.com/micheam/go-docbase v0.0.0-20210416150124-4da631f57116
 	github.com/pelletier/go-toml v1.9.4
+	github.com/russross/blackfriday/v2 v2.0.1
 	github.com/urfave/cli/v2 v2.3.0
+	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c
 	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.0-20190314233015-f79a8a8ca69d // indirect
-	github.com/russross/blackfriday/v2 v2.0.1 // indirect
 	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
-	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 )
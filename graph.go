@@ -0,0 +1,190 @@
+package docbasecli
+
+// メモ間のリンクの関係
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/micheam/go-docbase"
+)
+
+// PostLinks は、メモ post の本文からリンクしているメモの ID を重複なく出現順に返す
+func PostLinks(domain string, post docbase.Post) []docbase.PostID {
+	linkedPost := postLinkMatcher(domain)
+	var ids []docbase.PostID
+	seen := map[docbase.PostID]bool{}
+	for _, l := range ExtractLinks(post.Body) {
+		if id, ok := linkedPost(l.URL); ok && id != post.ID && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+/***************************************
+ * Backlinks
+ ***************************************/
+
+type BacklinksRequest struct {
+	Domain string
+	ID     docbase.PostID
+}
+
+// Backlinks は、メモ req.ID にリンクしているメモを handle に渡す。
+// 全文検索で候補を絞り込んだ上で、本文のリンクを解析して確かめる。
+func Backlinks(ctx context.Context, req BacklinksRequest, handle PostCollectionHandler) error {
+	log.Printf("find backlinks with req: %v", req)
+	query := fmt.Sprintf("posts/%d", req.ID)
+	candidates, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &query})
+	if err != nil {
+		return err
+	}
+	posts := FilterBacklinks(req.Domain, req.ID, candidates)
+	return handle(ctx, posts, docbase.Meta{Total: len(posts)})
+}
+
+// FilterBacklinks は、posts のうちメモ id にリンクしているものを返す
+func FilterBacklinks(domain string, id docbase.PostID, posts []docbase.Post) []docbase.Post {
+	found := []docbase.Post{}
+	for _, post := range posts {
+		for _, to := range PostLinks(domain, post) {
+			if to == id {
+				found = append(found, post)
+				break
+			}
+		}
+	}
+	return found
+}
+
+/***************************************
+ * Graph
+ ***************************************/
+
+// GraphFormat は、リンクの関係の出力形式
+type GraphFormat string
+
+const (
+	GraphDOT  GraphFormat = "dot"
+	GraphJSON GraphFormat = "json"
+)
+
+// ParseGraphFormat は、文字列を GraphFormat に変換する。空文字は GraphDOT とみなす。
+func ParseGraphFormat(s string) (GraphFormat, error) {
+	switch f := GraphFormat(s); f {
+	case "":
+		return GraphDOT, nil
+	case GraphDOT, GraphJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported format %q (dot or json)", s)
+}
+
+// PostGraph は、メモをノード、メモ間のリンクを辺とするグラフ
+type PostGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	ID    docbase.PostID `json:"id"`
+	Title string         `json:"title"`
+	URL   string         `json:"url"`
+	Tags  []string       `json:"tags"`
+}
+
+type GraphEdge struct {
+	From docbase.PostID `json:"from"`
+	To   docbase.PostID `json:"to"`
+}
+
+type GraphHandler func(ctx context.Context, g PostGraph) error
+
+type GraphRequest struct {
+	Domain string
+	Query  string
+}
+
+// Graph は、req.Query に一致するメモの間のリンクの関係を handle に渡す
+func Graph(ctx context.Context, req GraphRequest, handle GraphHandler) error {
+	log.Printf("build graph with req: %v", req)
+	posts, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &req.Query})
+	if err != nil {
+		return err
+	}
+	return handle(ctx, BuildGraph(req.Domain, posts))
+}
+
+// BuildGraph は、posts の間のリンクの関係をグラフにする。
+// posts に含まれないメモへのリンクは含めない。
+func BuildGraph(domain string, posts []docbase.Post) PostGraph {
+	g := PostGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	inGraph := map[docbase.PostID]bool{}
+	for _, post := range posts {
+		inGraph[post.ID] = true
+	}
+	for _, post := range posts {
+		node := GraphNode{ID: post.ID, Title: post.Title, URL: post.URL, Tags: []string{}}
+		for _, tag := range post.Tags {
+			node.Tags = append(node.Tags, tag.Name)
+		}
+		g.Nodes = append(g.Nodes, node)
+		for _, to := range PostLinks(domain, post) {
+			if inGraph[to] {
+				g.Edges = append(g.Edges, GraphEdge{From: post.ID, To: to})
+			}
+		}
+	}
+	sort.SliceStable(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// OutputGraph は、グラフを format で指定された形式で出力する
+func OutputGraph(out io.Writer, format GraphFormat) GraphHandler {
+	return func(ctx context.Context, g PostGraph) error {
+		if format == GraphJSON {
+			return writeJSON(out, g)
+		}
+		return writeDOT(out, g)
+	}
+}
+
+// writeDOT は、グラフを Graphviz の DOT 言語で出力する。
+// タグはノードの tags 属性として出力する。
+func writeDOT(out io.Writer, g PostGraph) error {
+	sb := new(strings.Builder)
+	sb.WriteString("digraph docbase {\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(sb, "  %d [label=%s", n.ID, dotQuote(n.Title))
+		if n.URL != "" {
+			fmt.Fprintf(sb, ", URL=%s", dotQuote(n.URL))
+		}
+		if len(n.Tags) > 0 {
+			fmt.Fprintf(sb, ", tags=%s", dotQuote(strings.Join(n.Tags, ",")))
+		}
+		sb.WriteString("];\n")
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(sb, "  %d -> %d;\n", e.From, e.To)
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
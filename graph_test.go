@@ -0,0 +1,117 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+var graphPosts = []docbase.Post{
+	{ID: 3, Title: `Say "hi"`, Tags: []docbase.Tag{{Name: "a"}, {Name: "b"}},
+		Body: "[one](https://example.docbase.io/posts/1) [self](/posts/3) [other team](https://other.docbase.io/posts/2)"},
+	{ID: 1, Title: "One", URL: "https://example.docbase.io/posts/1",
+		Body: "[three](/posts/3)\n[three again](https://example.docbase.io/posts/3#top)\n[outside](/posts/9)"},
+	{ID: 2, Title: "Two", Body: "mentions posts/1 without link"},
+}
+
+func TestPostLinks(t *testing.T) {
+	tests := []struct {
+		post docbase.Post
+		want []docbase.PostID
+	}{
+		{graphPosts[0], []docbase.PostID{1}},
+		{graphPosts[1], []docbase.PostID{3, 9}},
+		{graphPosts[2], nil},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, PostLinks("example", tt.post)); diff != "" {
+			t.Errorf("PostLinks(%d) mismatch (-want, +got):%s\n", tt.post.ID, diff)
+		}
+	}
+}
+
+func TestFilterBacklinks(t *testing.T) {
+	tests := []struct {
+		id   docbase.PostID
+		want []docbase.PostID
+	}{
+		{1, []docbase.PostID{3}},
+		{3, []docbase.PostID{1}},
+		{2, []docbase.PostID{}},
+	}
+	for _, tt := range tests {
+		got := []docbase.PostID{}
+		for _, p := range FilterBacklinks("example", tt.id, graphPosts) {
+			got = append(got, p.ID)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("FilterBacklinks(%d) mismatch (-want, +got):%s\n", tt.id, diff)
+		}
+	}
+}
+
+func TestOutputGraph(t *testing.T) {
+	g := BuildGraph("example", graphPosts)
+	tests := []struct {
+		format GraphFormat
+		want   string
+	}{
+		{GraphDOT, `digraph docbase {
+  node [shape=box];
+  1 [label="One", URL="https://example.docbase.io/posts/1"];
+  2 [label="Two"];
+  3 [label="Say \"hi\"", tags="a,b"];
+  1 -> 3;
+  3 -> 1;
+}
+`},
+		{GraphJSON, `{
+  "nodes": [
+    {
+      "id": 1,
+      "title": "One",
+      "url": "https://example.docbase.io/posts/1",
+      "tags": []
+    },
+    {
+      "id": 2,
+      "title": "Two",
+      "url": "",
+      "tags": []
+    },
+    {
+      "id": 3,
+      "title": "Say \"hi\"",
+      "url": "",
+      "tags": [
+        "a",
+        "b"
+      ]
+    }
+  ],
+  "edges": [
+    {
+      "from": 1,
+      "to": 3
+    },
+    {
+      "from": 3,
+      "to": 1
+    }
+  ]
+}
+`},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		if err := OutputGraph(buf, tt.format)(context.Background(), g); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+			t.Errorf("%s output mismatch (-want, +got):%s\n", tt.format, diff)
+		}
+	}
+}
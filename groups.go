@@ -0,0 +1,303 @@
+package docbasecli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/micheam/go-docbase"
+)
+
+// Group は DocBase のグループ
+type Group struct {
+	ID             int            `json:"id"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description,omitempty"`
+	PostsCount     int            `json:"posts_count,omitempty"`
+	LastActivityAt string         `json:"last_activity_at,omitempty"`
+	CreatedAt      string         `json:"created_at,omitempty"`
+	Users          []docbase.User `json:"users,omitempty"`
+}
+
+// define ResultHandlers
+type (
+	GroupHandler           func(ctx context.Context, g Group) error
+	GroupCollectionHandler func(ctx context.Context, gs []Group) error
+)
+
+/***************************************
+ * List Groups
+ ***************************************/
+
+type ListGroupsRequest struct {
+	Domain  string
+	Name    *string
+	Page    *int
+	PerPage *int
+}
+
+// MaxGroupsPerPage グループ検索APIで１ページあたりに取得できる最大件数
+const MaxGroupsPerPage = 200
+
+func ListGroups(ctx context.Context, req ListGroupsRequest, handle GroupCollectionHandler) error {
+	log.Printf("list groups with req: %v", req)
+	groups, err := listGroups(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to list groups: %w", err)
+	}
+	return handle(ctx, groups)
+}
+
+// ListAllGroups は、全ページを取得してからまとめて handle を呼び出す
+func ListAllGroups(ctx context.Context, req ListGroupsRequest, handle GroupCollectionHandler) error {
+	var all []Group
+	perPage := MaxGroupsPerPage
+	req.PerPage = &perPage
+	for page := 1; ; page++ {
+		p := page
+		req.Page = &p
+		groups, err := listGroups(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to list groups: %w", err)
+		}
+		all = append(all, groups...)
+		if len(groups) < perPage {
+			break
+		}
+	}
+	return handle(ctx, all)
+}
+
+func listGroups(ctx context.Context, req ListGroupsRequest) ([]Group, error) {
+	param := url.Values{}
+	if req.Name != nil {
+		param.Add("name", *req.Name)
+	}
+	if req.Page != nil {
+		param.Add("page", fmt.Sprint(*req.Page))
+	}
+	if req.PerPage != nil {
+		param.Add("per_page", fmt.Sprint(*req.PerPage))
+	}
+	r, err := newRequest(ctx, http.MethodGet, buildURL("teams", req.Domain, "groups"), nil, param)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]Group, 0)
+	err = RetryOnRateLimit(ctx, func() error { return doRequest(r, &groups) })
+	return groups, err
+}
+
+/***************************************
+ * Get Group
+ ***************************************/
+
+type GetGroupRequest struct {
+	Domain string
+	ID     int
+}
+
+func GetGroup(ctx context.Context, req GetGroupRequest, handle GroupHandler) error {
+	log.Printf("get group with req: %v", req)
+	r, err := newRequest(ctx, http.MethodGet, buildURL("teams", req.Domain, "groups", strconv.Itoa(req.ID)), nil, nil)
+	if err != nil {
+		return err
+	}
+	var group Group
+	if err := doRequest(r, &group); err != nil {
+		return fmt.Errorf("failed to get group(%d): %w", req.ID, err)
+	}
+	return handle(ctx, group)
+}
+
+/***************************************
+ * Group Resolver
+ ***************************************/
+
+// GroupCacheTTL グループ名と ID の対応をキャッシュする期間
+var GroupCacheTTL = 24 * time.Hour
+
+type groupCache struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Groups    []Group   `json:"groups"`
+}
+
+// ResolveGroup は、グループ名もしくは ID を表す文字列から Group を特定する。
+// グループの一覧は CacheDir に保存され、
+// キャッシュに見つからない場合のみ API から再取得する。
+func ResolveGroup(ctx context.Context, domain, nameOrID string) (*Group, error) {
+	groups, fresh, err := loadGroupCache(domain)
+	if err != nil {
+		log.Printf("ignore group cache: %v", err)
+	}
+	if g := findGroup(groups, nameOrID); g != nil {
+		return g, nil
+	}
+	if !fresh || len(groups) == 0 {
+		err := ListAllGroups(ctx, ListGroupsRequest{Domain: domain}, func(_ context.Context, gs []Group) error {
+			groups = gs
+			return saveGroupCache(domain, gs)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if g := findGroup(groups, nameOrID); g != nil {
+			return g, nil
+		}
+	}
+	return nil, fmt.Errorf("group %q not found", nameOrID)
+}
+
+// ResolveGroupIDs は、グループ名もしくは ID のリストを ID のリストに変換する
+func ResolveGroupIDs(ctx context.Context, domain string, namesOrIDs []string) ([]int, error) {
+	ids := make([]int, 0, len(namesOrIDs))
+	for _, s := range namesOrIDs {
+		g, err := ResolveGroup(ctx, domain, s)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, g.ID)
+	}
+	return ids, nil
+}
+
+func findGroup(groups []Group, nameOrID string) *Group {
+	id, err := strconv.Atoi(nameOrID)
+	for i := range groups {
+		if groups[i].Name == nameOrID || (err == nil && groups[i].ID == id) {
+			return &groups[i]
+		}
+	}
+	for i := range groups {
+		if strings.EqualFold(groups[i].Name, nameOrID) {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+func groupCachePath(domain string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, domain, "groups.json"), nil
+}
+
+// loadGroupCache は、キャッシュされたグループ一覧と、それが有効期限内であるかを返す
+func loadGroupCache(domain string) ([]Group, bool, error) {
+	path, err := groupCachePath(domain)
+	if err != nil {
+		return nil, false, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var c groupCache
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, false, err
+	}
+	return c.Groups, time.Since(c.FetchedAt) < GroupCacheTTL, nil
+}
+
+func saveGroupCache(domain string, groups []Group) error {
+	path, err := groupCachePath(domain)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(groupCache{FetchedAt: time.Now(), Groups: groups})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0o600)
+}
+
+/***************************************
+ * Presenters
+ ***************************************/
+
+// OutputGroups は、グループの一覧を format で指定された形式で出力する
+func OutputGroups(out io.Writer, format Format) GroupCollectionHandler {
+	return func(ctx context.Context, groups []Group) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, groups)
+		case FormatCSV:
+			records := make([][]string, len(groups))
+			for i, g := range groups {
+				records[i] = []string{strconv.Itoa(g.ID), g.Name}
+			}
+			return writeCSV(out, []string{"id", "name"}, records)
+		}
+		for _, g := range groups {
+			if _, err := fmt.Fprintf(out, "%d\t%s\n", g.ID, g.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// OutputGroupDetail は、グループの詳細を format で指定された形式で出力する
+func OutputGroupDetail(out io.Writer, format Format) GroupHandler {
+	return func(ctx context.Context, g Group) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, g)
+		case FormatCSV:
+			return writeCSV(out,
+				[]string{"id", "name", "description", "posts_count", "members", "last_activity_at", "created_at"},
+				[][]string{{strconv.Itoa(g.ID), g.Name, g.Description, strconv.Itoa(g.PostsCount),
+					strconv.Itoa(len(g.Users)), g.LastActivityAt, g.CreatedAt}})
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 1, ' ', 0)
+		fmt.Fprintf(w, "ID:\t%d\n", g.ID)
+		fmt.Fprintf(w, "Name:\t%s\n", g.Name)
+		fmt.Fprintf(w, "Description:\t%s\n", g.Description)
+		fmt.Fprintf(w, "Posts:\t%d\n", g.PostsCount)
+		fmt.Fprintf(w, "Members:\t%d\n", len(g.Users))
+		fmt.Fprintf(w, "LastActivityAt:\t%s\n", g.LastActivityAt)
+		fmt.Fprintf(w, "CreatedAt:\t%s\n", g.CreatedAt)
+		return w.Flush()
+	}
+}
+
+// OutputGroupMembers は、グループに所属するユーザーを format で指定された形式で出力する
+func OutputGroupMembers(out io.Writer, format Format) GroupHandler {
+	return func(ctx context.Context, g Group) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, g.Users)
+		case FormatCSV:
+			records := make([][]string, len(g.Users))
+			for i, u := range g.Users {
+				records[i] = []string{strconv.Itoa(int(u.ID)), u.Name}
+			}
+			return writeCSV(out, []string{"id", "name"}, records)
+		}
+		for _, u := range g.Users {
+			if _, err := fmt.Fprintf(out, "%d\t%s\n", u.ID, u.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
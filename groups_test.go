@@ -0,0 +1,65 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResolveGroup(t *testing.T) {
+	t.Setenv("DOCBASE_CACHE_DIR", t.TempDir())
+	var calls int
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/teams/example/groups" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode([]Group{{ID: 1, Name: "Backend"}, {ID: 2, Name: "Frontend"}})
+	}))
+	ctx := context.Background()
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"Backend", 1},
+		{"frontend", 2},
+		{"2", 2},
+	}
+	for _, tt := range tests {
+		g, err := ResolveGroup(ctx, "example", tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.ID != tt.want {
+			t.Errorf("ResolveGroup(%q): want %d, got %d", tt.in, tt.want, g.ID)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("groups must be fetched once and cached, but fetched %d times", calls)
+	}
+	if _, err := ResolveGroup(ctx, "example", "Unknown"); err == nil {
+		t.Errorf("want error for unknown group")
+	}
+}
+
+func TestOutputGroupMembers(t *testing.T) {
+	var g Group
+	err := json.Unmarshal([]byte(`{
+		"id": 1, "name": "Backend",
+		"users": [{"id": 10, "name": "alice"}, {"id": 20, "name": "bob"}]
+	}`), &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := OutputGroupMembers(buf, FormatText)(context.Background(), g); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("10\talice\n20\tbob\n", buf.String()); diff != "" {
+		t.Errorf("output mismatch (-want, +got):%s\n", diff)
+	}
+}
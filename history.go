@@ -0,0 +1,196 @@
+package docbasecli
+
+// メモの編集履歴
+//
+// DocBase API は編集履歴の取得を提供していないため、
+// このコマンドでメモを更新するたびに、更新前後のタイトルと本文を
+// CacheDir/<domain>/history/<ID>.json に記録する (RecordRevisions)。
+// ライブラリとして UpatePost を呼び出しただけでは記録しない。
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/micheam/go-docbase"
+)
+
+// Revision は、メモのある時点のタイトルと本文
+type Revision struct {
+	Rev     int       `json:"rev"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+	Editor  string    `json:"editor,omitempty"`
+	SavedAt time.Time `json:"saved_at"`
+
+	// Delta 直前のリビジョンからの本文のバイト数の増減
+	Delta int `json:"delta"`
+}
+
+type RevisionCollectionHandler func(ctx context.Context, revs []Revision) error
+
+// HistoryPath は、メモ id の編集履歴を記録するファイルのパスを返す
+func HistoryPath(domain string, id docbase.PostID) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, domain, "history", id.String()+".json"), nil
+}
+
+// LoadRevisions は、メモ id の編集履歴を古い順に返す。
+// 記録がない場合は空のスライスを返す。
+func LoadRevisions(domain string, id docbase.PostID) ([]Revision, error) {
+	path, err := HistoryPath(domain, id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []Revision{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var revs []Revision
+	if err := json.Unmarshal(b, &revs); err != nil {
+		return nil, fmt.Errorf("failed to parse history %q: %w", path, err)
+	}
+	for i := range revs {
+		revs[i].Delta = len(revs[i].Body)
+		if i > 0 {
+			revs[i].Delta -= len(revs[i-1].Body)
+		}
+	}
+	return revs, nil
+}
+
+// RecordRevisions は、更新前のメモ previous (nil の場合は省略) と更新後のメモを
+// 編集履歴に記録してから next に渡す PostHandler を返す。
+// 記録に失敗しても更新自体は成功しているため、ログに出力して続ける。
+func RecordRevisions(domain string, previous *docbase.Post, editor string, next PostHandler) PostHandler {
+	return func(ctx context.Context, post docbase.Post) error {
+		now := time.Now()
+		if previous != nil {
+			if _, err := RecordRevision(domain, *previous, "", now); err != nil {
+				log.Printf("failed to record revision of post(%d): %v", post.ID, err)
+			}
+		}
+		if _, err := RecordRevision(domain, post, editor, now); err != nil {
+			log.Printf("failed to record revision of post(%d): %v", post.ID, err)
+		}
+		return next(ctx, post)
+	}
+}
+
+// RecordRevision は、post の現在の内容を新しいリビジョンとして記録する。
+// 直前のリビジョンとタイトル・本文が同じ場合は記録せず、そのリビジョンを返す。
+func RecordRevision(domain string, post docbase.Post, editor string, at time.Time) (*Revision, error) {
+	revs, err := LoadRevisions(domain, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(revs); n > 0 && revs[n-1].Title == post.Title && revs[n-1].Body == post.Body {
+		return &revs[n-1], nil
+	}
+	rev := Revision{
+		Rev:     len(revs) + 1,
+		Title:   post.Title,
+		Body:    post.Body,
+		Editor:  editor,
+		SavedAt: at,
+	}
+	revs = append(revs, rev)
+
+	path, err := HistoryPath(domain, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(revs, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, b, 0o600); err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+/***************************************
+ * List Revisions
+ ***************************************/
+
+type ListRevisionsRequest struct {
+	Domain string
+	ID     docbase.PostID
+}
+
+func ListRevisions(ctx context.Context, req ListRevisionsRequest, handle RevisionCollectionHandler) error {
+	revs, err := LoadRevisions(req.Domain, req.ID)
+	if err != nil {
+		return err
+	}
+	return handle(ctx, revs)
+}
+
+/***************************************
+ * Get Revision
+ ***************************************/
+
+type GetRevisionRequest struct {
+	Domain string
+	ID     docbase.PostID
+	Rev    int
+}
+
+// GetRevision は、メモ req.ID のリビジョン req.Rev を返す
+func GetRevision(ctx context.Context, req GetRevisionRequest) (*Revision, error) {
+	revs, err := LoadRevisions(req.Domain, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revs {
+		if revs[i].Rev == req.Rev {
+			return &revs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d of post(%d): %w", req.Rev, req.ID, ErrNotFound)
+}
+
+// OutputRevisions は、編集履歴を format で指定された形式で出力する
+func OutputRevisions(out io.Writer, format Format) RevisionCollectionHandler {
+	return func(ctx context.Context, revs []Revision) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, revs)
+		case FormatCSV:
+			records := make([][]string, len(revs))
+			for i, r := range revs {
+				records[i] = []string{strconv.Itoa(r.Rev), r.SavedAt.Format(time.RFC3339), r.Editor, strconv.Itoa(r.Delta), r.Title}
+			}
+			return writeCSV(out, []string{"rev", "saved_at", "editor", "delta", "title"}, records)
+		}
+		for _, r := range revs {
+			editor := r.Editor
+			if editor == "" {
+				editor = "-"
+			}
+			_, err := fmt.Fprintf(out, "%d\t%s\t%s\t%+d\t%s\n",
+				r.Rev, r.SavedAt.Format("2006-01-02 15:04:05"), editor, r.Delta, r.Title)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,87 @@
+package docbasecli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestRecordRevision(t *testing.T) {
+	t.Setenv("DOCBASE_CACHE_DIR", t.TempDir())
+	at := time.Date(2026, 10, 19, 15, 4, 0, 0, time.UTC)
+	post := docbase.Post{ID: 1234, Title: "memo", Body: "hello\n"}
+
+	for i, p := range []docbase.Post{
+		post,
+		post, // 変更がない場合は記録しない
+		{ID: 1234, Title: "memo", Body: "hello\nworld\n"},
+		{ID: 1234, Title: "renamed", Body: "hello\n"},
+	} {
+		if _, err := RecordRevision("example", p, "micheam", at.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []Revision{
+		{Rev: 1, Title: "memo", Body: "hello\n", Editor: "micheam", SavedAt: at, Delta: 6},
+		{Rev: 2, Title: "memo", Body: "hello\nworld\n", Editor: "micheam", SavedAt: at.Add(2 * time.Minute), Delta: 6},
+		{Rev: 3, Title: "renamed", Body: "hello\n", Editor: "micheam", SavedAt: at.Add(3 * time.Minute), Delta: -6},
+	}
+	var got []Revision
+	err := ListRevisions(context.Background(), ListRevisionsRequest{Domain: "example", ID: 1234},
+		func(_ context.Context, revs []Revision) error {
+			got = revs
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("revisions mismatch (-want, +got):%s\n", diff)
+	}
+
+	rev, err := GetRevision(context.Background(), GetRevisionRequest{Domain: "example", ID: 1234, Rev: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want[1], *rev); diff != "" {
+		t.Errorf("revision mismatch (-want, +got):%s\n", diff)
+	}
+	if _, err := GetRevision(context.Background(), GetRevisionRequest{Domain: "example", ID: 1234, Rev: 4}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+}
+
+func TestRecordRevisions(t *testing.T) {
+	t.Setenv("DOCBASE_CACHE_DIR", t.TempDir())
+	previous := docbase.Post{ID: 1234, Title: "memo", Body: "hello\n"}
+	updated := docbase.Post{ID: 1234, Title: "memo", Body: "hello\nworld\n"}
+
+	var handled []docbase.PostID
+	handle := RecordRevisions("example", &previous, "micheam", func(_ context.Context, post docbase.Post) error {
+		handled = append(handled, post.ID)
+		return nil
+	})
+	if err := handle(context.Background(), updated); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]docbase.PostID{1234}, handled); diff != "" {
+		t.Errorf("handled posts mismatch (-want, +got):%s\n", diff)
+	}
+	revs, err := LoadRevisions("example", 1234)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range revs {
+		got = append(got, r.Editor+":"+r.Body)
+	}
+	want := []string{":hello\n", "micheam:hello\nworld\n"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("revisions mismatch (-want, +got):%s\n", diff)
+	}
+}
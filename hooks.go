@@ -0,0 +1,133 @@
+package docbasecli
+
+// メモの作成・更新・削除の前後に実行するフック
+//
+// フックは sh -c で実行するコマンドで、対象のメモを JSON で標準入力に受け取る。
+// pre フックが 0 以外で終了した場合は操作を中止する。
+// pre-create, pre-update のフックには本文を環境変数 DOCBASE_BODY_FILE のファイルで渡し、
+// フックがこのファイルを書き換えた場合のみ、その内容を新しい本文とする。
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/micheam/go-docbase"
+)
+
+// HookEvent は、フックを実行する契機
+type HookEvent string
+
+const (
+	HookPreCreate  HookEvent = "pre-create"
+	HookPostCreate HookEvent = "post-create"
+	HookPreUpdate  HookEvent = "pre-update"
+	HookPostUpdate HookEvent = "post-update"
+	HookPreDelete  HookEvent = "pre-delete"
+)
+
+// Hooks は、イベント名ごとのフックのコマンド (e.g. {"pre-create": "./check.sh"})
+type Hooks map[string]string
+
+// hookStderr は、フックの標準エラー出力と、失敗を警告する出力先 (テスト時に差し替える)
+var hookStderr io.Writer = os.Stderr
+
+// HookPayload は、フックの標準入力に渡す JSON
+type HookPayload struct {
+	Event  HookEvent    `json:"event"`
+	Domain string       `json:"domain"`
+	Post   docbase.Post `json:"post"`
+}
+
+// Has は、event のフックが設定されているかを判定する
+func (h Hooks) Has(event HookEvent) bool {
+	return strings.TrimSpace(h[string(event)]) != ""
+}
+
+// Run は、event のフックを post を渡して実行し、標準出力に書き出された内容を返す。
+// フックが設定されていない場合は何もしない。
+func (h Hooks) Run(ctx context.Context, event HookEvent, domain string, post docbase.Post) (string, error) {
+	return h.run(ctx, event, domain, post)
+}
+
+// run は、env を追加した環境変数で event のフックを実行する
+func (h Hooks) run(ctx context.Context, event HookEvent, domain string, post docbase.Post, env ...string) (string, error) {
+	if !h.Has(event) {
+		return "", nil
+	}
+	command := h[string(event)]
+	log.Printf("run %s hook: %q", event, command)
+	in, err := json.Marshal(HookPayload{Event: event, Domain: domain, Post: post})
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stderr = hookStderr
+	cmd.Env = append(os.Environ(),
+		"DOCBASE_HOOK="+string(event),
+		"DOCBASE_DOMAIN="+domain,
+		"DOCBASE_POST_ID="+post.ID.String(),
+		"DOCBASE_POST_TITLE="+post.Title,
+		"DOCBASE_POST_URL="+post.URL,
+	)
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s hook %q failed: %w", event, command, err)
+	}
+	return string(out), nil
+}
+
+// transform は、pre フックに post と本文 body を渡し、フックを実行した後の本文を返す。
+// 本文は DOCBASE_BODY_FILE のファイルで渡し、フックの標準出力は本文にしない。
+func (h Hooks) transform(ctx context.Context, event HookEvent, domain string, post docbase.Post, body io.Reader) (io.Reader, error) {
+	if !h.Has(event) || body == nil {
+		return body, nil
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile("", "docbase-hook-*.md")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	post.Body = string(b)
+	if _, err := h.run(ctx, event, domain, post, "DOCBASE_BODY_FILE="+f.Name()); err != nil {
+		return nil, err
+	}
+	if b, err = ioutil.ReadFile(f.Name()); err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+// After は、event のフックを実行してから next にメモを渡す PostHandler を返す。
+// 操作自体は完了しているため、フックの失敗はエラーにせず標準エラー出力に警告する。
+func (h Hooks) After(event HookEvent, domain string, next PostHandler) PostHandler {
+	if !h.Has(event) {
+		return next
+	}
+	return func(ctx context.Context, post docbase.Post) error {
+		if _, err := h.Run(ctx, event, domain, post); err != nil {
+			fmt.Fprintf(hookStderr, "warning: %v\n", err)
+		}
+		return next(ctx, post)
+	}
+}
@@ -0,0 +1,110 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestHooks_Run(t *testing.T) {
+	post := docbase.Post{ID: 42, Title: "Memo", Body: "hello"}
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr bool
+	}{
+		{"not configured", "", "", false},
+		{"env", `printf '%s %s %s %s' "$DOCBASE_HOOK" "$DOCBASE_DOMAIN" "$DOCBASE_POST_ID" "$DOCBASE_POST_TITLE"`, "pre-update example 42 Memo", false},
+		{"payload", `tr -d '\n'`, `{"event":"pre-update","domain":"example","post":`, false},
+		{"veto", "echo 'no title' >&2; exit 1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := Hooks{string(HookPreUpdate): tt.command}
+			got, err := hooks.Run(context.Background(), HookPreUpdate, "example", post)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, but got %v", tt.wantErr, err)
+			}
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("want output to start with %q, but got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHooks_transform(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"replace body", `printf '%s (checked)' "$(cat "$DOCBASE_BODY_FILE")" > "$DOCBASE_BODY_FILE"`, "draft (checked)"},
+		{"keep body", "cat > /dev/null", "draft"},
+		{"ignore stdout", "echo ok", "draft"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := Hooks{string(HookPreCreate): tt.command}
+			r, err := hooks.transform(context.Background(), HookPreCreate, "example", docbase.Post{}, strings.NewReader("draft"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("body mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestHooks_After(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	orig := hookStderr
+	hookStderr = stderr
+	t.Cleanup(func() { hookStderr = orig })
+
+	for _, command := range []string{"true", "exit 1"} {
+		stderr.Reset()
+		var called bool
+		hooks := Hooks{string(HookPostCreate): command}
+		h := hooks.After(HookPostCreate, "example", func(context.Context, docbase.Post) error {
+			called = true
+			return nil
+		})
+		if err := h(context.Background(), docbase.Post{ID: 1}); err != nil {
+			t.Errorf("%q: want nil, but got %v", command, err)
+		}
+		if !called {
+			t.Errorf("%q: want next handler to be called", command)
+		}
+		// フックの失敗は --verbose でなくても警告する
+		if warned := strings.HasPrefix(stderr.String(), "warning: "); warned != (command != "true") {
+			t.Errorf("%q: unexpected warning %q", command, stderr.String())
+		}
+	}
+}
+
+func TestCreatePost_vetoedByHook(t *testing.T) {
+	req := CreatePostRequest{
+		Domain: "example",
+		Title:  "memo",
+		Body:   strings.NewReader("body"),
+		Hooks:  Hooks{string(HookPreCreate): "exit 3"},
+	}
+	err := CreatePost(context.Background(), req, func(context.Context, docbase.Post) error {
+		t.Error("post must not be created")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "pre-create hook") {
+		t.Errorf("want pre-create hook error, but got %v", err)
+	}
+}
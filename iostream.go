@@ -1,7 +1,12 @@
 package docbasecli
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"strings"
 
 	"github.com/mattn/go-isatty"
 )
@@ -9,3 +14,71 @@ import (
 func IsTerminal(f *os.File) bool {
 	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
 }
+
+// Confirm は、out に prompt を表示して in から y/N の回答を読み取る。
+// y もしくは yes (大文字小文字は問わない) が入力された場合のみ true を返す。
+func Confirm(in io.Reader, out io.Writer, prompt string) (bool, error) {
+	answer, err := Ask(in, out, prompt+" [y/N]")
+	if err != nil {
+		return false, err
+	}
+	switch answer {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// Ask は、out に prompt を表示して in から１行読み取り、
+// 前後の空白を除いて小文字にしたものを返す。
+func Ask(in io.Reader, out io.Writer, prompt string) (string, error) {
+	if _, err := fmt.Fprintf(out, "%s: ", prompt); err != nil {
+		return "", err
+	}
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.ToLower(strings.TrimSpace(line)), nil
+}
+
+// TerminalWidth は、端末 f の桁数を返す。端末でない場合は 0 を返す。
+func TerminalWidth(f *os.File) int {
+	if !IsTerminal(f) {
+		return 0
+	}
+	width, _, err := terminalSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// DefaultPager 環境変数 PAGER が設定されていない場合のページャ
+const DefaultPager = "less"
+
+// Page は、s が端末 out の高さに収まらない場合はページャ ($PAGER) で表示し、
+// それ以外の場合は out にそのまま書き出す。
+func Page(out *os.File, s string) error {
+	height := 0
+	if IsTerminal(out) {
+		_, height, _ = terminalSize(int(out.Fd()))
+	}
+	if height == 0 || strings.Count(s, "\n") < height {
+		_, err := io.WriteString(out, s)
+		return err
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = DefaultPager
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(s)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// 色付けを有効にし、１画面に収まる場合はそのまま終了する
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,71 @@
+package docbasecli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/micheam/go-docbase"
+)
+
+// DefaultWeeklyTitleTemplate journal --weekly で weekly テンプレートが無い場合のタイトル
+const DefaultWeeklyTitleTemplate = "{{.Week}} 作業メモ"
+
+type FindPostRequest struct {
+	Domain string
+	Title  string
+	Tags   []string
+	// Author 指定した場合は、このユーザーID のメモのみを対象とする
+	Author string
+}
+
+// FindPostByTitle は、タイトルが Title と完全に一致するメモを検索し、
+// 最初に見つかったものを handle に渡す。見つからない場合は ErrNotFound を返す。
+func FindPostByTitle(ctx context.Context, req FindPostRequest, handle PostHandler) error {
+	terms := []string{"title:" + QuoteQuery(req.Title)}
+	for _, tag := range req.Tags {
+		terms = append(terms, "tag:"+QuoteQuery(tag))
+	}
+	if req.Author != "" {
+		terms = append(terms, "author:"+req.Author)
+	}
+	query := strings.Join(terms, " ")
+	log.Printf("find post with query: %s", query)
+
+	var found *docbase.Post
+	err := ListAllPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &query},
+		func(_ context.Context, posts []docbase.Post, _ docbase.Meta) error {
+			for i := range posts {
+				if found == nil && posts[i].Title == req.Title {
+					found = &posts[i]
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+	if found == nil {
+		return fmt.Errorf("%w: title %q", ErrNotFound, req.Title)
+	}
+	return handle(ctx, *found)
+}
+
+// AppendJournalEntry は、body の末尾に時刻の見出しを付けて entry を追記する
+func AppendJournalEntry(body string, at time.Time, entry string) string {
+	body = strings.TrimRight(body, "\n")
+	if body != "" {
+		body += "\n\n"
+	}
+	return body + "### " + at.Format("15:04") + "\n\n" + strings.TrimRight(entry, "\n") + "\n"
+}
+
+// QuoteQuery は、空白を含む検索条件を引用符で囲む
+func QuoteQuery(s string) string {
+	if strings.ContainsAny(s, " 　") {
+		return `"` + s + `"`
+	}
+	return s
+}
@@ -0,0 +1,47 @@
+package docbasecli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAppendJournalEntry(t *testing.T) {
+	at := time.Date(2026, 10, 19, 15, 4, 0, 0, time.Local)
+	tests := []struct {
+		name, body, entry, want string
+	}{
+		{
+			name:  "empty body",
+			entry: "deployed v1.2.3\n",
+			want:  "### 15:04\n\ndeployed v1.2.3\n",
+		},
+		{
+			name:  "existing body",
+			body:  "## TODO\n- review\n\n\n",
+			entry: "deployed v1.2.3",
+			want:  "## TODO\n- review\n\n### 15:04\n\ndeployed v1.2.3\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AppendJournalEntry(tt.body, at, tt.entry)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("body mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestQuoteQuery(t *testing.T) {
+	for in, want := range map[string]string{
+		"Backend":         "Backend",
+		"2026-10-19 作業メモ": `"2026-10-19 作業メモ"`,
+		"2026-10-19　作業メモ": `"2026-10-19　作業メモ"`,
+	} {
+		if got := QuoteQuery(in); got != want {
+			t.Errorf("QuoteQuery(%q): want %q, got %q", in, want, got)
+		}
+	}
+}
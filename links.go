@@ -0,0 +1,296 @@
+package docbasecli
+
+// メモ本文中のリンクの検査
+//
+// 削除・アーカイブされたメモや、非公開のメモへのリンクを見つける。
+// 外部の URL は、指定された場合のみ HEAD リクエストで到達できるかを確かめる。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+	"github.com/russross/blackfriday/v2"
+)
+
+// LinkStatus は、リンクの検査結果
+type LinkStatus string
+
+const (
+	LinkOK       LinkStatus = "ok"
+	LinkMissing  LinkStatus = "missing"  // リンク先のメモが存在しない
+	LinkArchived LinkStatus = "archived" // リンク先のメモがアーカイブされている
+	LinkPrivate  LinkStatus = "private"  // リンク先のメモが非公開
+	LinkBroken   LinkStatus = "broken"   // 外部の URL がエラーを返した
+	LinkError    LinkStatus = "error"    // 検査に失敗した
+)
+
+// DefaultLinkTimeout 外部の URL １件あたりの検査の制限時間
+const DefaultLinkTimeout = 10 * time.Second
+
+// Link は、メモ本文中のリンク
+type Link struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+// LinkFinding は、問題のあるリンク
+type LinkFinding struct {
+	Link
+	Status LinkStatus `json:"status"`
+	Detail string     `json:"detail,omitempty"`
+}
+
+// LinkReport は、メモ１件に含まれる問題のあるリンク
+type LinkReport struct {
+	ID       docbase.PostID `json:"id"`
+	Title    string         `json:"title"`
+	URL      string         `json:"url"`
+	Findings []LinkFinding  `json:"findings"`
+}
+
+type LinkReportHandler func(ctx context.Context, reports []LinkReport) error
+
+// ExtractLinks は、Markdown の本文に含まれるリンクを出現順に返す
+func ExtractLinks(body string) []Link {
+	var links []Link
+	doc := blackfriday.New(blackfriday.WithExtensions(markdownExtensions)).Parse([]byte(text.Dos2Unix(body)))
+	doc.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && n.Type == blackfriday.Link {
+			links = append(links, Link{URL: string(n.LinkData.Destination), Text: inlineText(n)})
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	return links
+}
+
+// postLinkMatcher は、URL が domain のメモへのリンクであればその ID を返す関数を作る。
+// 絶対 URL のほか、"/posts/ID" 形式の相対 URL も対象とする。
+func postLinkMatcher(domain string) func(url string) (docbase.PostID, bool) {
+	re := regexp.MustCompile(`^(?:` + postLinkPattern(domain) + `|/posts/(\d+))`)
+	return func(url string) (docbase.PostID, bool) {
+		m := re.FindStringSubmatch(url)
+		if m == nil {
+			return 0, false
+		}
+		s := m[1]
+		if s == "" {
+			s = m[3]
+		}
+		id, err := docbase.ParsePostID(s)
+		return id, err == nil
+	}
+}
+
+/***************************************
+ * Check Links
+ ***************************************/
+
+type CheckLinksRequest struct {
+	Domain string
+	Query  string
+
+	// External 外部の URL も検査する
+	External bool
+
+	// Concurrency 同時に検査するリンクの数
+	Concurrency int
+
+	// Timeout 外部の URL １件あたりの制限時間。省略した場合は DefaultLinkTimeout
+	Timeout time.Duration
+}
+
+// CheckLinks は、req.Query に一致するメモのリンクを検査し、
+// 問題のあるリンクを含むメモの一覧を handle に渡す
+func CheckLinks(ctx context.Context, req CheckLinksRequest, handle LinkReportHandler) error {
+	log.Printf("check links with req: %v", req)
+	posts, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &req.Query})
+	if err != nil {
+		return err
+	}
+	reports, err := CheckPostLinks(ctx, req, posts)
+	if err != nil {
+		return err
+	}
+	return handle(ctx, reports)
+}
+
+// CheckPostLinks は、posts のリンクを検査して問題のあるリンクを含むメモの一覧を返す。
+// 同じリンク先は一度だけ検査する。
+func CheckPostLinks(ctx context.Context, req CheckLinksRequest, posts []docbase.Post) ([]LinkReport, error) {
+	if req.Timeout == 0 {
+		req.Timeout = DefaultLinkTimeout
+	}
+	linkedPost := postLinkMatcher(req.Domain)
+
+	type target struct {
+		id  docbase.PostID // 0 の場合は外部の URL
+		url string
+	}
+	links := make([][]Link, len(posts))
+	var targets []target
+	seen := map[target]bool{}
+	targetOf := func(l Link) (target, bool) {
+		if id, ok := linkedPost(l.URL); ok {
+			return target{id: id}, true
+		}
+		if req.External && (strings.HasPrefix(l.URL, "http://") || strings.HasPrefix(l.URL, "https://")) {
+			return target{url: l.URL}, true
+		}
+		return target{}, false
+	}
+	for i, post := range posts {
+		links[i] = ExtractLinks(post.Body)
+		for _, l := range links[i] {
+			if t, ok := targetOf(l); ok && !seen[t] {
+				seen[t] = true
+				targets = append(targets, t)
+			}
+		}
+	}
+
+	// リンク先ごとの検査結果
+	type result struct {
+		status LinkStatus
+		detail string
+	}
+	results := make(map[target]result, len(targets))
+	var mu sync.Mutex
+	concurrency := req.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			var r result
+			if t.id != 0 {
+				r.status, r.detail = checkPostLink(ctx, req.Domain, t.id)
+			} else {
+				r.status, r.detail = checkExternalLink(ctx, t.url, req.Timeout)
+			}
+			mu.Lock()
+			results[t] = r
+			mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	reports := []LinkReport{}
+	for i, post := range posts {
+		var findings []LinkFinding
+		for _, l := range links[i] {
+			t, ok := targetOf(l)
+			if !ok {
+				continue
+			}
+			if r := results[t]; r.status != LinkOK {
+				findings = append(findings, LinkFinding{Link: l, Status: r.status, Detail: r.detail})
+			}
+		}
+		if len(findings) > 0 {
+			reports = append(reports, LinkReport{ID: post.ID, Title: post.Title, URL: post.URL, Findings: findings})
+		}
+	}
+	return reports, nil
+}
+
+// checkPostLink は、メモ id へのリンクを検査する
+func checkPostLink(ctx context.Context, domain string, id docbase.PostID) (LinkStatus, string) {
+	r, err := newRequest(ctx, http.MethodGet, buildURL("teams", domain, "posts", id.String()), nil, nil)
+	if err != nil {
+		return LinkError, err.Error()
+	}
+	var post docbase.Post
+	err = RetryOnRateLimit(ctx, func() error { return doRequest(r, &post) })
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return LinkMissing, fmt.Sprintf("post(%d) not found", id)
+	case err != nil:
+		return LinkError, err.Error()
+	case post.Archived:
+		return LinkArchived, post.Title
+	case post.Scope == docbase.ScopePrivate:
+		return LinkPrivate, post.Title
+	}
+	return LinkOK, ""
+}
+
+// checkExternalLink は、外部の URL に HEAD リクエストを送って到達できるかを検査する。
+// HEAD に対応していないサーバーには GET で問い合わせる。
+func checkExternalLink(ctx context.Context, url string, timeout time.Duration) (LinkStatus, string) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var status int
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return LinkError, err.Error()
+		}
+		log.Println(req.Method, req.URL)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return LinkError, err.Error()
+		}
+		_ = resp.Body.Close()
+		status = resp.StatusCode
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			break
+		}
+	}
+	if 400 <= status {
+		return LinkBroken, fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	return LinkOK, ""
+}
+
+// OutputLinkReports は、リンクの検査結果を format で指定された形式で出力する
+func OutputLinkReports(out io.Writer, format Format) LinkReportHandler {
+	return func(ctx context.Context, reports []LinkReport) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, reports)
+		case FormatCSV:
+			var records [][]string
+			for _, r := range reports {
+				for _, f := range r.Findings {
+					records = append(records, []string{r.ID.String(), r.Title, string(f.Status), f.URL, f.Detail})
+				}
+			}
+			return writeCSV(out, []string{"id", "title", "status", "url", "detail"}, records)
+		}
+		for _, r := range reports {
+			if _, err := fmt.Fprintf(out, "%d\t%s\n", r.ID, r.Title); err != nil {
+				return err
+			}
+			for _, f := range r.Findings {
+				line := fmt.Sprintf("\t%s\t%s", f.Status, f.URL)
+				if f.Detail != "" {
+					line += "\t" + f.Detail
+				}
+				if _, err := fmt.Fprintln(out, line); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,140 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestExtractLinks(t *testing.T) {
+	body := "See [setup](https://example.docbase.io/posts/2) and https://example.com\r\n![img](https://example.com/a.png)\n`https://example.com/code`"
+	want := []Link{
+		{URL: "https://example.docbase.io/posts/2", Text: "setup"},
+		{URL: "https://example.com", Text: "https://example.com"},
+	}
+	if diff := cmp.Diff(want, ExtractLinks(body)); diff != "" {
+		t.Errorf("ExtractLinks mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestCheckPostLinks(t *testing.T) {
+	var apiCalls int32
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		switch r.URL.Path {
+		case "/teams/example/posts/2":
+			_, _ = w.Write([]byte(`{"id": 2, "title": "OK", "scope": "everyone"}`))
+		case "/teams/example/posts/3":
+			_, _ = w.Write([]byte(`{"id": 3, "title": "Old", "archived": true, "scope": "everyone"}`))
+		case "/teams/example/posts/4":
+			_, _ = w.Write([]byte(`{"id": 4, "title": "Mine", "scope": "private"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(external.Close)
+
+	posts := []docbase.Post{
+		{ID: 1, Title: "Index", URL: "https://example.docbase.io/posts/1", Body: "" +
+			"- [ok](https://example.docbase.io/posts/2)\n" +
+			"- [old](https://example.docbase.io/posts/3#section)\n" +
+			"- [mine](/posts/4)\n" +
+			"- [gone](https://example.docbase.io/posts/5)\n" +
+			"- [ext](" + external.URL + "/ok)\n" +
+			"- [no head](" + external.URL + "/no-head)\n" +
+			"- [dead](" + external.URL + "/dead)\n"},
+		{ID: 6, Title: "Clean", Body: "[ok](https://example.docbase.io/posts/2)"},
+		{ID: 7, Title: "Dup", Body: "[gone again](https://example.docbase.io/posts/5)"},
+	}
+
+	tests := []struct {
+		name string
+		req  CheckLinksRequest
+		want []LinkReport
+	}{
+		{
+			name: "internal only",
+			req:  CheckLinksRequest{Domain: "example", Concurrency: 2},
+			want: []LinkReport{
+				{ID: 1, Title: "Index", URL: "https://example.docbase.io/posts/1", Findings: []LinkFinding{
+					{Link: Link{URL: "https://example.docbase.io/posts/3#section", Text: "old"}, Status: LinkArchived, Detail: "Old"},
+					{Link: Link{URL: "/posts/4", Text: "mine"}, Status: LinkPrivate, Detail: "Mine"},
+					{Link: Link{URL: "https://example.docbase.io/posts/5", Text: "gone"}, Status: LinkMissing, Detail: "post(5) not found"},
+				}},
+				{ID: 7, Title: "Dup", Findings: []LinkFinding{
+					{Link: Link{URL: "https://example.docbase.io/posts/5", Text: "gone again"}, Status: LinkMissing, Detail: "post(5) not found"},
+				}},
+			},
+		},
+		{
+			name: "with external",
+			req:  CheckLinksRequest{Domain: "example", External: true, Concurrency: 4},
+			want: []LinkReport{
+				{ID: 1, Title: "Index", URL: "https://example.docbase.io/posts/1", Findings: []LinkFinding{
+					{Link: Link{URL: "https://example.docbase.io/posts/3#section", Text: "old"}, Status: LinkArchived, Detail: "Old"},
+					{Link: Link{URL: "/posts/4", Text: "mine"}, Status: LinkPrivate, Detail: "Mine"},
+					{Link: Link{URL: "https://example.docbase.io/posts/5", Text: "gone"}, Status: LinkMissing, Detail: "post(5) not found"},
+					{Link: Link{URL: external.URL + "/dead", Text: "dead"}, Status: LinkBroken, Detail: "404 Not Found"},
+				}},
+				{ID: 7, Title: "Dup", Findings: []LinkFinding{
+					{Link: Link{URL: "https://example.docbase.io/posts/5", Text: "gone again"}, Status: LinkMissing, Detail: "post(5) not found"},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&apiCalls, 0)
+			got, err := CheckPostLinks(context.Background(), tt.req, posts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("CheckPostLinks mismatch (-want, +got):%s\n", diff)
+			}
+			if n := atomic.LoadInt32(&apiCalls); n != 4 {
+				t.Errorf("want each post checked once, but api called %d times", n)
+			}
+		})
+	}
+}
+
+func TestOutputLinkReports(t *testing.T) {
+	reports := []LinkReport{{ID: 1, Title: "Index", Findings: []LinkFinding{
+		{Link: Link{URL: "/posts/4", Text: "mine"}, Status: LinkPrivate, Detail: "Mine"},
+		{Link: Link{URL: "https://example.com/dead"}, Status: LinkBroken},
+	}}}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatText, "1\tIndex\n\tprivate\t/posts/4\tMine\n\tbroken\thttps://example.com/dead\n"},
+		{FormatCSV, "id,title,status,url,detail\n1,Index,private,/posts/4,Mine\n1,Index,broken,https://example.com/dead,\n"},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		if err := OutputLinkReports(buf, tt.format)(context.Background(), reports); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+			t.Errorf("%s output mismatch (-want, +got):%s\n", tt.format, diff)
+		}
+	}
+}
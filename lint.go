@@ -0,0 +1,44 @@
+package docbasecli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/micheam/docbase-cli/text"
+)
+
+// LintResult は、ファイルもしくはメモ１件の Lint の結果
+type LintResult struct {
+	Target string           `json:"target"`
+	Issues []text.LintIssue `json:"issues"`
+}
+
+type LintResultHandler func(ctx context.Context, results []LintResult) error
+
+// OutputLintResults は、Lint の結果を format で指定された形式で出力する
+func OutputLintResults(out io.Writer, format Format) LintResultHandler {
+	return func(ctx context.Context, results []LintResult) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, results)
+		case FormatCSV:
+			var records [][]string
+			for _, r := range results {
+				for _, i := range r.Issues {
+					records = append(records, []string{r.Target, strconv.Itoa(i.Line), i.Rule, i.Message, strconv.FormatBool(i.Fixable)})
+				}
+			}
+			return writeCSV(out, []string{"target", "line", "rule", "message", "fixable"}, records)
+		}
+		for _, r := range results {
+			for _, i := range r.Issues {
+				if _, err := fmt.Fprintf(out, "%s:%s\n", r.Target, i); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/docbase-cli/text"
+)
+
+func TestOutputLintResults(t *testing.T) {
+	results := []LintResult{{Target: "README.md", Issues: []text.LintIssue{
+		{Rule: text.RuleRequiredSection, Line: 0, Message: `section "Why" is required for tag "adr"`},
+		{Rule: text.RuleTrailingSpace, Line: 3, Message: "trailing whitespace", Fixable: true},
+	}}}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatText, "README.md:0: [required-section] section \"Why\" is required for tag \"adr\"\nREADME.md:3: [trailing-space] trailing whitespace\n"},
+		{FormatCSV, "target,line,rule,message,fixable\nREADME.md,0,required-section,\"section \"\"Why\"\" is required for tag \"\"adr\"\"\",false\nREADME.md,3,trailing-space,trailing whitespace,true\n"},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		if err := OutputLintResults(buf, tt.format)(context.Background(), results); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+			t.Errorf("%s output mismatch (-want, +got):%s\n", tt.format, diff)
+		}
+	}
+}
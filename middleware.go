@@ -0,0 +1,216 @@
+package docbasecli
+
+// PostHandler / PostCollectionHandler の組み立て
+//
+// コマンドごとに結果の処理を書く代わりに、絞り込みや並べ替え、出力を組み合わせて使う。
+//
+//	handle := Compose(WriteTo(os.Stdout, FormatText),
+//		Filter(func(p docbase.Post) bool { return !p.Archived }),
+//		Sort(func(a, b docbase.Post) bool { return a.UpdatedAt > b.UpdatedAt }),
+//		Limit(10),
+//	)
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/micheam/go-docbase"
+)
+
+// PostMiddleware は、メモの一覧を加工してから next に渡す PostCollectionHandler を返す
+type PostMiddleware func(next PostCollectionHandler) PostCollectionHandler
+
+// Compose は、メモの一覧を middlewares に先頭から順に通した上で handle に渡す PostCollectionHandler を返す
+func Compose(handle PostCollectionHandler, middlewares ...PostMiddleware) PostCollectionHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handle = middlewares[i](handle)
+	}
+	return handle
+}
+
+// Tee は、メモの一覧を handlers に順に渡す PostCollectionHandler を返す。
+// いずれかがエラーを返した場合は、そこで中断する。
+func Tee(handlers ...PostCollectionHandler) PostCollectionHandler {
+	return func(ctx context.Context, posts []docbase.Post, meta docbase.Meta) error {
+		for _, h := range handlers {
+			if err := h(ctx, posts, meta); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Filter は、keep が true を返すメモのみを残す。
+// meta は検索結果全体を表すため変更しない。
+func Filter(keep func(post docbase.Post) bool) PostMiddleware {
+	return func(next PostCollectionHandler) PostCollectionHandler {
+		return func(ctx context.Context, posts []docbase.Post, meta docbase.Meta) error {
+			kept := []docbase.Post{}
+			for _, post := range posts {
+				if keep(post) {
+					kept = append(kept, post)
+				}
+			}
+			return next(ctx, kept, meta)
+		}
+	}
+}
+
+// Map は、メモを f で変換する
+func Map(f func(post docbase.Post) docbase.Post) PostMiddleware {
+	return func(next PostCollectionHandler) PostCollectionHandler {
+		return func(ctx context.Context, posts []docbase.Post, meta docbase.Meta) error {
+			mapped := make([]docbase.Post, len(posts))
+			for i, post := range posts {
+				mapped[i] = f(post)
+			}
+			return next(ctx, mapped, meta)
+		}
+	}
+}
+
+// Sort は、メモを less の順に並べ替える (安定ソート)。元の一覧は変更しない。
+func Sort(less func(a, b docbase.Post) bool) PostMiddleware {
+	return func(next PostCollectionHandler) PostCollectionHandler {
+		return func(ctx context.Context, posts []docbase.Post, meta docbase.Meta) error {
+			sorted := append([]docbase.Post{}, posts...)
+			sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+			return next(ctx, sorted, meta)
+		}
+	}
+}
+
+// Limit は、先頭の n 件のみを残す。n が 0 以下の場合は制限しない。
+func Limit(n int) PostMiddleware {
+	return func(next PostCollectionHandler) PostCollectionHandler {
+		return func(ctx context.Context, posts []docbase.Post, meta docbase.Meta) error {
+			if n > 0 && len(posts) > n {
+				posts = posts[:n]
+			}
+			return next(ctx, posts, meta)
+		}
+	}
+}
+
+// Log は、受け取ったメモの件数を label を付けてログに出力する
+func Log(label string) PostMiddleware {
+	return func(next PostCollectionHandler) PostCollectionHandler {
+		return func(ctx context.Context, posts []docbase.Post, meta docbase.Meta) error {
+			log.Printf("%s: %d posts (total %d)", label, len(posts), meta.Total)
+			return next(ctx, posts, meta)
+		}
+	}
+}
+
+// PostCache は、受け取ったメモを ID ごとに保持する。並行して使用できる。
+type PostCache struct {
+	mu    sync.RWMutex
+	posts map[docbase.PostID]docbase.Post
+}
+
+func NewPostCache() *PostCache {
+	return &PostCache{posts: map[docbase.PostID]docbase.Post{}}
+}
+
+// Get は、ID が id のメモを返す
+func (c *PostCache) Get(id docbase.PostID) (docbase.Post, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	post, ok := c.posts[id]
+	return post, ok
+}
+
+// Len は、保持しているメモの件数を返す
+func (c *PostCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.posts)
+}
+
+func (c *PostCache) put(posts []docbase.Post) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, post := range posts {
+		c.posts[post.ID] = post
+	}
+}
+
+// Cache は、受け取ったメモを cache に保存してから次に渡す
+func Cache(cache *PostCache) PostMiddleware {
+	return func(next PostCollectionHandler) PostCollectionHandler {
+		return func(ctx context.Context, posts []docbase.Post, meta docbase.Meta) error {
+			cache.put(posts)
+			return next(ctx, posts, meta)
+		}
+	}
+}
+
+// WriteTo は、メモの一覧を format で指定された形式で out に出力する。
+// text の場合は list と同じく１件１行で出力する。
+func WriteTo(out io.Writer, format Format) PostCollectionHandler {
+	return func(ctx context.Context, posts []docbase.Post, _ docbase.Meta) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, posts)
+		case FormatCSV:
+			records := make([][]string, 0, len(posts))
+			for _, p := range posts {
+				tags := make([]string, len(p.Tags))
+				for i, tag := range p.Tags {
+					tags[i] = tag.Name
+				}
+				records = append(records, []string{p.ID.String(), p.Title, p.User.Name, p.UpdatedAt, strings.Join(tags, " "), p.URL})
+			}
+			return writeCSV(out, []string{"id", "title", "user", "updated_at", "tags", "url"}, records)
+		}
+		for _, p := range posts {
+			if _, err := fmt.Fprintf(out, "%d\t%s\n", p.ID, summarizePost(p)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+/***************************************
+ * Single Post
+ ***************************************/
+
+// Each は、一覧のメモを１件ずつ handle に渡す PostCollectionHandler を返す
+func Each(handle PostHandler) PostCollectionHandler {
+	return func(ctx context.Context, posts []docbase.Post, _ docbase.Meta) error {
+		for _, post := range posts {
+			if err := handle(ctx, post); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Single は、メモ１件を一覧として handle に渡す PostHandler を返す。
+// 一覧向けに組み立てたものをメモ１件の処理にも使うためのもの。
+func Single(handle PostCollectionHandler) PostHandler {
+	return func(ctx context.Context, post docbase.Post) error {
+		return handle(ctx, []docbase.Post{post}, docbase.Meta{Total: 1})
+	}
+}
+
+// PrintURL は、message (空でない場合) とメモの URL を出力する PostHandler を返す
+func PrintURL(out io.Writer, message string) PostHandler {
+	return func(ctx context.Context, post docbase.Post) error {
+		if message != "" {
+			if _, err := fmt.Fprintln(out, message); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintln(out, post.URL)
+		return err
+	}
+}
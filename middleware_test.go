@@ -0,0 +1,196 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+var middlewarePosts = []docbase.Post{
+	{ID: 3, Title: "Charlie", UpdatedAt: "2021-04-03T00:00:00+09:00", URL: "https://example.docbase.io/posts/3"},
+	{ID: 1, Title: "Alpha", UpdatedAt: "2021-04-01T00:00:00+09:00", Archived: true},
+	{ID: 2, Title: "Bravo", UpdatedAt: "2021-04-02T00:00:00+09:00", Tags: []docbase.Tag{{Name: "a"}, {Name: "b"}}},
+}
+
+// collect は、受け取ったメモの ID を記録する PostCollectionHandler を返す
+func collect(got *[]docbase.PostID) PostCollectionHandler {
+	return func(_ context.Context, posts []docbase.Post, _ docbase.Meta) error {
+		*got = []docbase.PostID{}
+		for _, p := range posts {
+			*got = append(*got, p.ID)
+		}
+		return nil
+	}
+}
+
+func TestMiddlewares(t *testing.T) {
+	byID := func(a, b docbase.Post) bool { return a.ID < b.ID }
+	tests := []struct {
+		name        string
+		middlewares []PostMiddleware
+		want        []docbase.PostID
+	}{
+		{"none", nil, []docbase.PostID{3, 1, 2}},
+		{"filter", []PostMiddleware{Filter(func(p docbase.Post) bool { return !p.Archived })}, []docbase.PostID{3, 2}},
+		{"filter all", []PostMiddleware{Filter(func(docbase.Post) bool { return false })}, []docbase.PostID{}},
+		{"map", []PostMiddleware{Map(func(p docbase.Post) docbase.Post { p.ID *= 10; return p })}, []docbase.PostID{30, 10, 20}},
+		{"sort", []PostMiddleware{Sort(byID)}, []docbase.PostID{1, 2, 3}},
+		{"sort by updated desc", []PostMiddleware{Sort(func(a, b docbase.Post) bool { return a.UpdatedAt > b.UpdatedAt })}, []docbase.PostID{3, 2, 1}},
+		{"limit", []PostMiddleware{Limit(2)}, []docbase.PostID{3, 1}},
+		{"limit over", []PostMiddleware{Limit(5)}, []docbase.PostID{3, 1, 2}},
+		{"limit zero", []PostMiddleware{Limit(0)}, []docbase.PostID{3, 1, 2}},
+		{"log", []PostMiddleware{Log("test")}, []docbase.PostID{3, 1, 2}},
+		{"compose in order", []PostMiddleware{Sort(byID), Limit(2)}, []docbase.PostID{1, 2}},
+		{"compose limit first", []PostMiddleware{Limit(2), Sort(byID)}, []docbase.PostID{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []docbase.PostID
+			handle := Compose(collect(&got), tt.middlewares...)
+			if err := handle(context.Background(), middlewarePosts, docbase.Meta{Total: 3}); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("posts mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+	if middlewarePosts[0].ID != 3 {
+		t.Error("middlewares must not modify the original posts")
+	}
+}
+
+func TestTee(t *testing.T) {
+	errStop := errors.New("stop")
+	fail := func(context.Context, []docbase.Post, docbase.Meta) error { return errStop }
+	tests := []struct {
+		name    string
+		build   func(a, b *[]docbase.PostID) PostCollectionHandler
+		wantA   []docbase.PostID
+		wantB   []docbase.PostID
+		wantErr error
+	}{
+		{
+			name: "all handlers",
+			build: func(a, b *[]docbase.PostID) PostCollectionHandler {
+				return Tee(collect(a), Compose(collect(b), Limit(1)))
+			},
+			wantA: []docbase.PostID{3, 1, 2},
+			wantB: []docbase.PostID{3},
+		},
+		{
+			name: "stop on error",
+			build: func(a, b *[]docbase.PostID) PostCollectionHandler {
+				return Tee(collect(a), fail, collect(b))
+			},
+			wantA:   []docbase.PostID{3, 1, 2},
+			wantErr: errStop,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b []docbase.PostID
+			err := tt.build(&a, &b)(context.Background(), middlewarePosts, docbase.Meta{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error %v, but got %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.wantA, a); diff != "" {
+				t.Errorf("first handler mismatch (-want, +got):%s\n", diff)
+			}
+			if diff := cmp.Diff(tt.wantB, b); diff != "" {
+				t.Errorf("second handler mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := NewPostCache()
+	handle := Compose(func(context.Context, []docbase.Post, docbase.Meta) error { return nil }, Cache(cache), Limit(1))
+	if err := handle(context.Background(), middlewarePosts, docbase.Meta{}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		id     docbase.PostID
+		want   string
+		wantOK bool
+	}{
+		{1, "Alpha", true},
+		{3, "Charlie", true},
+		{4, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := cache.Get(tt.id)
+		if ok != tt.wantOK || got.Title != tt.want {
+			t.Errorf("Get(%d): want (%q, %v), but got (%q, %v)", tt.id, tt.want, tt.wantOK, got.Title, ok)
+		}
+	}
+	if cache.Len() != 3 {
+		t.Errorf("want 3 posts cached, but got %d", cache.Len())
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	posts := middlewarePosts[1:]
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatText, "1\t[archived] Alpha\n2\tBravo #a #b\n"},
+		{FormatCSV, "id,title,user,updated_at,tags,url\n1,Alpha,,2021-04-01T00:00:00+09:00,,\n2,Bravo,,2021-04-02T00:00:00+09:00,a b,\n"},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		if err := WriteTo(buf, tt.format)(context.Background(), posts, docbase.Meta{}); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+			t.Errorf("%s output mismatch (-want, +got):%s\n", tt.format, diff)
+		}
+	}
+}
+
+func TestEachAndSingle(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(buf *bytes.Buffer) error
+		want   string
+	}{
+		{
+			name: "each",
+			handle: func(buf *bytes.Buffer) error {
+				return Each(PrintURL(buf, ""))(context.Background(), middlewarePosts[:1], docbase.Meta{})
+			},
+			want: "https://example.docbase.io/posts/3\n",
+		},
+		{
+			name: "single",
+			handle: func(buf *bytes.Buffer) error {
+				return Single(WriteTo(buf, FormatText))(context.Background(), middlewarePosts[2])
+			},
+			want: "2\tBravo #a #b\n",
+		},
+		{
+			name: "print url with message",
+			handle: func(buf *bytes.Buffer) error {
+				return PrintURL(buf, "Updated.")(context.Background(), middlewarePosts[0])
+			},
+			want: "Updated.\nhttps://example.docbase.io/posts/3\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.handle(buf); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,511 @@
+package docbasecli
+
+// 対話的なメモの選択 (fuzzy finder)
+//
+// 入力した文字列で手元の検索結果を絞り込みつつ、入力が止まると
+// その文字列をクエリとして API で検索し直す。
+//
+//	Enter: 決定  Tab: 選択の切り替え (複数選択時)  Esc/Ctrl-C: 中止
+//	↑/Ctrl-P/Ctrl-K, ↓/Ctrl-N/Ctrl-J: カーソル移動  Ctrl-U: 入力の消去  Ctrl-W: １語削除
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+)
+
+// ErrPickCanceled メモの選択が中止された
+var ErrPickCanceled = errors.New("canceled")
+
+// SearchDelay 入力が止まってから API で検索し直すまでの待ち時間
+var SearchDelay = 300 * time.Millisecond
+
+// PostSource は、検索クエリ query に一致するメモを返す
+type PostSource func(ctx context.Context, query string) ([]docbase.Post, error)
+
+type PickPostsRequest struct {
+	Source PostSource
+	Multi  bool // 複数のメモの選択を許可する
+
+	// Command 指定した場合は組み込みの選択画面の代わりに fzf 互換の外部コマンドを使う。
+	// コマンドには "ID<TAB>要約" の形式で１行ずつメモを渡す。
+	Command string
+}
+
+// PickPosts は、req.Source から取得したメモを端末上で選択させ、選択されたメモを返す
+func PickPosts(ctx context.Context, req PickPostsRequest) ([]docbase.Post, error) {
+	if req.Command != "" {
+		return pickPostsWithCommand(ctx, req)
+	}
+	return pickPostsInteractive(ctx, req)
+}
+
+func pickPostsWithCommand(ctx context.Context, req PickPostsRequest) ([]docbase.Post, error) {
+	posts, err := req.Source(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	in := new(bytes.Buffer)
+	byID := make(map[docbase.PostID]docbase.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+		fmt.Fprintf(in, "%d\t%s\n", post.ID, pickerLabel(post))
+	}
+	command := req.Command
+	if req.Multi {
+		command += " --multi"
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = in
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// fzf は何も選択されなかった場合に 1 を、中止された場合に 130 を返す
+		return nil, ErrPickCanceled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %q: %w", req.Command, err)
+	}
+	var picked []docbase.Post
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		id, err := docbase.ParsePostID(strings.SplitN(line, "\t", 2)[0])
+		if err != nil {
+			continue
+		}
+		if post, ok := byID[id]; ok {
+			picked = append(picked, post)
+		}
+	}
+	if len(picked) == 0 {
+		return nil, ErrPickCanceled
+	}
+	return picked, nil
+}
+
+func pickPostsInteractive(ctx context.Context, req PickPostsRequest) ([]docbase.Post, error) {
+	tty, err := openTTY()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tty.Close() }()
+	fd := int(tty.Fd())
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = restore() }()
+	fmt.Fprint(tty, "\x1b[?1049h") // 代替スクリーンに切り替える
+	defer fmt.Fprint(tty, "\x1b[?1049l")
+
+	done := make(chan struct{})
+	defer close(done)
+	keys := make(chan []key)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 256)
+		for {
+			n, err := tty.Read(buf)
+			if err != nil {
+				return
+			}
+			select {
+			case keys <- parseKeys(buf[:n]):
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	type result struct {
+		seq   int
+		query string
+		posts []docbase.Post
+		err   error
+	}
+	var (
+		results = make(chan result)
+		issued  int // 最後に発行した検索の通し番号
+		shown   int // 表示中の検索結果の通し番号
+		timer   = time.NewTimer(0)
+		p       = newPicker(req.Multi)
+	)
+	defer timer.Stop()
+	for {
+		width, height, err := terminalSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		p.render(tty, width, height)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case ks, ok := <-keys:
+			if !ok {
+				return nil, ErrPickCanceled
+			}
+			for _, k := range ks {
+				changed, finished := p.handle(k)
+				if finished {
+					if p.canceled {
+						return nil, ErrPickCanceled
+					}
+					return p.result(), nil
+				}
+				if changed {
+					if !timer.Stop() {
+						select {
+						case <-timer.C:
+						default:
+						}
+					}
+					timer.Reset(SearchDelay)
+				}
+			}
+		case <-timer.C:
+			issued++
+			seq, query := issued, string(p.query)
+			p.searching = true
+			go func() {
+				posts, err := req.Source(ctx, query)
+				select {
+				case results <- result{seq, query, posts, err}:
+				case <-done:
+				}
+			}()
+		case r := <-results:
+			if r.seq < shown {
+				continue // より新しい検索結果を表示済み
+			}
+			shown = r.seq
+			p.searching = r.seq < issued
+			p.err = r.err
+			if r.err == nil {
+				p.setPosts(r.query, r.posts)
+			}
+		}
+	}
+}
+
+/***************************************
+ * Key input
+ ***************************************/
+
+type keyKind int
+
+const (
+	keyRune keyKind = iota
+	keyEnter
+	keyCancel
+	keyBackspace
+	keyClear
+	keyDeleteWord
+	keyUp
+	keyDown
+	keyToggle
+)
+
+type key struct {
+	kind keyKind
+	r    rune
+}
+
+// parseKeys は、raw モードの端末から読み取ったバイト列をキー入力に変換する
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		c := b[0]
+		switch {
+		case c == 0x1b && len(b) >= 2 && (b[1] == '[' || b[1] == 'O'):
+			// CSI/SS3 シーケンスは矢印キー以外は読み捨てる
+			i := 2
+			for i < len(b) && b[i] >= 0x20 && b[i] <= 0x3f {
+				i++
+			}
+			if i < len(b) {
+				switch b[i] {
+				case 'A':
+					keys = append(keys, key{kind: keyUp})
+				case 'B':
+					keys = append(keys, key{kind: keyDown})
+				}
+				i++
+			}
+			b = b[i:]
+			continue
+		case c == 0x1b, c == 0x03, c == 0x07: // Esc, Ctrl-C, Ctrl-G
+			keys = append(keys, key{kind: keyCancel})
+		case c == '\r':
+			keys = append(keys, key{kind: keyEnter})
+		case c == 0x7f, c == 0x08:
+			keys = append(keys, key{kind: keyBackspace})
+		case c == 0x15: // Ctrl-U
+			keys = append(keys, key{kind: keyClear})
+		case c == 0x17: // Ctrl-W
+			keys = append(keys, key{kind: keyDeleteWord})
+		case c == 0x10, c == 0x0b: // Ctrl-P, Ctrl-K
+			keys = append(keys, key{kind: keyUp})
+		case c == 0x0e, c == '\n': // Ctrl-N, Ctrl-J
+			keys = append(keys, key{kind: keyDown})
+		case c == '\t':
+			keys = append(keys, key{kind: keyToggle})
+		case c < 0x20:
+			// その他の制御文字は無視する
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r != utf8.RuneError {
+				keys = append(keys, key{kind: keyRune, r: r})
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+/***************************************
+ * Picker state
+ ***************************************/
+
+type picker struct {
+	multi bool
+	query []rune
+
+	posts       []docbase.Post // API の検索結果
+	resultQuery string         // posts を検索したクエリ
+	matches     []docbase.Post // posts を query で絞り込んだもの
+	selected    []docbase.Post
+
+	cursor, offset int
+	searching      bool
+	canceled       bool
+	err            error
+}
+
+func newPicker(multi bool) *picker {
+	return &picker{multi: multi}
+}
+
+// setPosts は、クエリ query で検索したメモを候補にする
+func (p *picker) setPosts(query string, posts []docbase.Post) {
+	p.posts, p.resultQuery = posts, query
+	p.filter()
+}
+
+// filter は、入力中のクエリで候補を絞り込む。
+// 入力中のクエリの検索結果が得られている場合は、API の結果をそのまま使う。
+func (p *picker) filter() {
+	query := string(p.query)
+	if query == p.resultQuery {
+		p.matches = p.posts
+	} else {
+		type scored struct {
+			post  docbase.Post
+			score int
+		}
+		var ss []scored
+		for _, post := range p.posts {
+			if score, ok := text.FuzzyMatch(query, pickerLabel(post)); ok {
+				ss = append(ss, scored{post, score})
+			}
+		}
+		sort.SliceStable(ss, func(i, j int) bool { return ss[i].score > ss[j].score })
+		p.matches = make([]docbase.Post, len(ss))
+		for i := range ss {
+			p.matches[i] = ss[i].post
+		}
+	}
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// handle は、キー入力 k を処理する。
+// クエリが変更された場合は changed を、選択が終了した場合は finished を返す。
+func (p *picker) handle(k key) (changed, finished bool) {
+	switch k.kind {
+	case keyRune:
+		p.query = append(p.query, k.r)
+		changed = true
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			changed = true
+		}
+	case keyClear:
+		changed = len(p.query) > 0
+		p.query = nil
+	case keyDeleteWord:
+		n := len(p.query)
+		for n > 0 && p.query[n-1] == ' ' {
+			n--
+		}
+		for n > 0 && p.query[n-1] != ' ' {
+			n--
+		}
+		changed = n != len(p.query)
+		p.query = p.query[:n]
+	case keyUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case keyDown:
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+	case keyToggle:
+		if p.multi && p.cursor < len(p.matches) {
+			p.toggle(p.matches[p.cursor])
+			if p.cursor < len(p.matches)-1 {
+				p.cursor++
+			}
+		}
+	case keyEnter:
+		return false, len(p.result()) > 0
+	case keyCancel:
+		p.canceled = true
+		return false, true
+	}
+	if changed {
+		p.filter()
+	}
+	return changed, false
+}
+
+func (p *picker) toggle(post docbase.Post) {
+	for i := range p.selected {
+		if p.selected[i].ID == post.ID {
+			p.selected = append(p.selected[:i], p.selected[i+1:]...)
+			return
+		}
+	}
+	p.selected = append(p.selected, post)
+}
+
+func (p *picker) isSelected(id docbase.PostID) bool {
+	for i := range p.selected {
+		if p.selected[i].ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// result は、選択されたメモを返す。
+// 何も選択されていない場合はカーソル位置のメモを返す。
+func (p *picker) result() []docbase.Post {
+	if len(p.selected) > 0 {
+		return p.selected
+	}
+	if p.cursor < len(p.matches) {
+		return []docbase.Post{p.matches[p.cursor]}
+	}
+	return nil
+}
+
+// render は、上部に入力欄と候補の一覧を、下部にカーソル位置のメモの本文を描画する
+func (p *picker) render(out io.Writer, width, height int) {
+	listHeight := height - 1
+	previewHeight := 0
+	if height >= 8 {
+		listHeight = (height - 2) / 2
+		previewHeight = height - 2 - listHeight
+	}
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+listHeight {
+		p.offset = p.cursor - listHeight + 1
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("\x1b[H")
+	// 最後の行の後に改行すると画面がスクロールするため、改行は行の間にのみ出力する
+	first := true
+	line := func(s string) {
+		if !first {
+			buf.WriteString("\r\n")
+		}
+		first = false
+		buf.WriteString(s + "\x1b[K")
+	}
+
+	status := fmt.Sprintf("%d/%d", len(p.matches), len(p.posts))
+	if p.multi {
+		status += fmt.Sprintf(" (%d)", len(p.selected))
+	}
+	switch {
+	case p.err != nil:
+		status = "error: " + p.err.Error()
+	case p.searching:
+		status = "searching... " + status
+	}
+	prompt := "> " + string(p.query)
+	pad := width - text.Width(prompt) - text.Width(status)
+	if pad < 1 {
+		pad = 1
+	}
+	line(text.Truncate(prompt+strings.Repeat(" ", pad)+status, width))
+
+	for i := p.offset; i < p.offset+listHeight; i++ {
+		if i >= len(p.matches) {
+			line("")
+			continue
+		}
+		post := p.matches[i]
+		mark := "  "
+		if p.isSelected(post.ID) {
+			mark = " *"
+		}
+		s := text.Truncate(fmt.Sprintf("%s %d\t%s", mark, post.ID, pickerLabel(post)), width)
+		s = strings.Replace(s, "\t", " ", 1)
+		if i == p.cursor {
+			s = "\x1b[7m" + text.PadRight(s, width) + "\x1b[0m"
+		}
+		line(s)
+	}
+
+	if previewHeight > 0 {
+		line(strings.Repeat("─", width))
+		var lines []string
+		if p.cursor < len(p.matches) {
+			body := strings.ReplaceAll(text.Dos2Unix(p.matches[p.cursor].Body), "\t", "    ")
+			lines = strings.Split(body, "\n")
+		}
+		for i := 0; i < previewHeight-1; i++ {
+			if i < len(lines) {
+				line(text.Truncate(lines[i], width))
+			} else {
+				line("")
+			}
+		}
+	}
+	buf.WriteString("\x1b[J")
+	fmt.Fprintf(buf, "\x1b[1;%dH", text.Width(prompt)+1)
+	_, _ = out.Write(buf.Bytes())
+}
+
+// pickerLabel は、候補の一覧に表示するメモの要約 (タイトル・タグ・作成者)
+func pickerLabel(post docbase.Post) string {
+	label := summarizePost(post)
+	if post.User.Name != "" {
+		label += " @" + post.User.Name
+	}
+	return label
+}
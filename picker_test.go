@@ -0,0 +1,103 @@
+package docbasecli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []key
+	}{
+		{
+			name: "runes",
+			in:   "aメ",
+			want: []key{{kind: keyRune, r: 'a'}, {kind: keyRune, r: 'メ'}},
+		},
+		{
+			name: "arrows",
+			in:   "\x1b[A\x1bOB",
+			want: []key{{kind: keyUp}, {kind: keyDown}},
+		},
+		{
+			name: "ignore other sequences",
+			in:   "\x1b[3~x",
+			want: []key{{kind: keyRune, r: 'x'}},
+		},
+		{
+			name: "controls",
+			in:   "\t\x7f\x15\x17\r\x1b",
+			want: []key{{kind: keyToggle}, {kind: keyBackspace}, {kind: keyClear}, {kind: keyDeleteWord}, {kind: keyEnter}, {kind: keyCancel}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseKeys([]byte(tt.in))
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(key{})); diff != "" {
+				t.Errorf("keys mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestPicker(t *testing.T) {
+	posts := []docbase.Post{
+		{ID: 1, Title: "2026-10-19 作業メモ"},
+		{ID: 2, Title: "リリース手順"},
+		{ID: 3, Title: "2026-10-18 作業メモ"},
+	}
+	ids := func(ps []docbase.Post) []docbase.PostID {
+		var ids []docbase.PostID
+		for _, p := range ps {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	p := newPicker(true)
+	p.setPosts("", posts)
+	for _, r := range "作業" {
+		if changed, _ := p.handle(key{kind: keyRune, r: r}); !changed {
+			t.Fatal("want query changed")
+		}
+	}
+	if diff := cmp.Diff([]docbase.PostID{1, 3}, ids(p.matches)); diff != "" {
+		t.Errorf("matches mismatch (-want, +got):%s\n", diff)
+	}
+
+	// API の検索結果が届いた場合は、そのまま候補にする
+	p.setPosts("作業", posts[1:])
+	if diff := cmp.Diff([]docbase.PostID{2, 3}, ids(p.matches)); diff != "" {
+		t.Errorf("matches mismatch (-want, +got):%s\n", diff)
+	}
+
+	p.handle(key{kind: keyToggle})
+	p.handle(key{kind: keyToggle})
+	p.handle(key{kind: keyUp})
+	p.handle(key{kind: keyToggle})
+	if _, finished := p.handle(key{kind: keyEnter}); !finished {
+		t.Fatal("want finished")
+	}
+	if diff := cmp.Diff([]docbase.PostID{3}, ids(p.result())); diff != "" {
+		t.Errorf("result mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestPicker_render(t *testing.T) {
+	p := newPicker(false)
+	p.setPosts("", []docbase.Post{{ID: 1, Title: "リリース手順", Body: "a\nb"}})
+	for _, height := range []int{5, 20} {
+		buf := new(bytes.Buffer)
+		p.render(buf, 40, height)
+		// 最後の行の後に改行すると画面がスクロールし、入力欄が隠れる
+		if n := strings.Count(buf.String(), "\r\n"); n >= height {
+			t.Errorf("height %d: want less than %d newlines, got %d", height, height, n)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package docbasecli
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
 	"strings"
@@ -24,14 +27,41 @@ type UpdatePostRequest struct {
 	Domain string
 	ID     docbase.PostID
 	Body   io.Reader
+
+	// Title 省略した場合はタイトルを変更しない
+	Title *string
+
+	// Previous 更新前のメモ。指定した場合は pre-update フックに渡す
+	Previous *docbase.Post
+
+	// Scanner 指定した場合は送信前に本文を検査し、秘密情報を含む場合は *SecretError を返す
+	// (Scanner.Confirm が nil を返した場合は送信する)
+	Scanner *SecretScanner
+
+	// Hooks 更新の前後に実行するフック (pre-update, post-update)
+	Hooks Hooks
 }
 
 func UpatePost(ctx context.Context, req UpdatePostRequest, handle PostHandler) error {
-	updated, err := docbase.UpdatePost(ctx, req.Domain, req.ID, req.Body, docbase.UpdateFields{})
+	target := docbase.Post{ID: req.ID}
+	if req.Previous != nil {
+		target = *req.Previous
+	}
+	if req.Title != nil {
+		target.Title = *req.Title
+	}
+	body, err := req.Hooks.transform(ctx, HookPreUpdate, req.Domain, target, req.Body)
+	if err != nil {
+		return err
+	}
+	if body, err = scanBody(req.Scanner, body); err != nil {
+		return err
+	}
+	updated, err := docbase.UpdatePost(ctx, req.Domain, req.ID, body, docbase.UpdateFields{Title: req.Title})
 	if err != nil {
 		return fmt.Errorf("failed to create new post: %w", err)
 	}
-	return handle(ctx, *updated)
+	return req.Hooks.After(HookPostUpdate, req.Domain, handle)(ctx, *updated)
 }
 
 /***************************************
@@ -80,6 +110,49 @@ func ListPosts(ctx context.Context, req ListPostsRequest, handle PostCollectionH
 	return handle(ctx, posts, *meta)
 }
 
+// MaxPerPage 検索APIで１ページあたりに取得できるメモの最大件数
+const MaxPerPage = 100
+
+// ListAllPosts は、検索結果の全ページを順に取得し、ページごとに handle を呼び出す。
+// req.Page は無視される。req.PerPage を省略した場合は MaxPerPage 件ずつ取得する。
+func ListAllPosts(ctx context.Context, req ListPostsRequest, handle PostCollectionHandler) error {
+	if req.PerPage == nil {
+		req.PerPage = pointer.IntPtr(MaxPerPage)
+	}
+	for page := 1; ; page++ {
+		req.Page = pointer.IntPtr(page)
+		var (
+			posts []docbase.Post
+			meta  docbase.Meta
+		)
+		err := RetryOnRateLimit(ctx, func() error {
+			return ListPosts(ctx, req, func(_ context.Context, ps []docbase.Post, m docbase.Meta) error {
+				posts, meta = ps, m
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+		if err := handle(ctx, posts, meta); err != nil {
+			return err
+		}
+		if meta.NextPageURL == "" || len(posts) == 0 {
+			return nil
+		}
+	}
+}
+
+// CollectPosts は、検索結果の全ページを取得してひとつのスライスにまとめる
+func CollectPosts(ctx context.Context, req ListPostsRequest) ([]docbase.Post, error) {
+	var all []docbase.Post
+	err := ListAllPosts(ctx, req, func(_ context.Context, ps []docbase.Post, _ docbase.Meta) error {
+		all = append(all, ps...)
+		return nil
+	})
+	return all, err
+}
+
 type CreatePostRequest struct {
 	Domain string
 	Title  string
@@ -88,6 +161,13 @@ type CreatePostRequest struct {
 	// Option メモ作成時のオプション
 	// 省略した場合は DefaultPostOption が適用される
 	Option *docbase.PostOption
+
+	// Scanner 指定した場合は送信前に本文を検査し、秘密情報を含む場合は *SecretError を返す
+	// (Scanner.Confirm が nil を返した場合は送信する)
+	Scanner *SecretScanner
+
+	// Hooks 作成の前後に実行するフック (pre-create, post-create)
+	Hooks Hooks
 }
 
 // DefaultPostOption メモ作成時のデフォルトオプション
@@ -103,11 +183,106 @@ func CreatePost(ctx context.Context, req CreatePostRequest, handler PostHandler)
 	if req.Option != nil {
 		opt = *req.Option
 	}
-	created, err := docbase.NewPost(ctx, req.Domain, req.Title, req.Body, opt)
+	target := docbase.Post{Title: req.Title, Draft: opt.Draft != nil && *opt.Draft, Scope: docbase.Scope(opt.Scope)}
+	for _, tag := range opt.Tags {
+		target.Tags = append(target.Tags, docbase.Tag{Name: tag})
+	}
+	body, err := req.Hooks.transform(ctx, HookPreCreate, req.Domain, target, req.Body)
+	if err != nil {
+		return err
+	}
+	if body, err = scanBody(req.Scanner, body); err != nil {
+		return err
+	}
+	created, err := docbase.NewPost(ctx, req.Domain, req.Title, body, opt)
 	if err != nil {
 		return fmt.Errorf("failed to create new post: %w", err)
 	}
-	return handler(ctx, *created)
+	return req.Hooks.After(HookPostCreate, req.Domain, handler)(ctx, *created)
+}
+
+// scanBody は、scanner で body を検査する。body を読み込むため、続けて送信するための Reader を返す。
+// scanner が nil の場合は検査しない。
+func scanBody(scanner *SecretScanner, body io.Reader) (io.Reader, error) {
+	if scanner == nil || body == nil {
+		return body, nil
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := scanner.Check(string(b)); err != nil {
+		var secretErr *SecretError
+		if scanner.Confirm == nil || !errors.As(err, &secretErr) {
+			return nil, err
+		}
+		if err := scanner.Confirm(secretErr); err != nil {
+			return nil, err
+		}
+	}
+	return bytes.NewReader(b), nil
+}
+
+/***************************************
+ * Delete Post
+ ***************************************/
+
+type DeletePostRequest struct {
+	Domain string
+	ID     docbase.PostID
+
+	// Hooks 削除の前に実行するフック (pre-delete)
+	Hooks Hooks
+}
+
+func DeletePost(ctx context.Context, req DeletePostRequest) error {
+	log.Printf("delete post with req: %v", req)
+	if req.Hooks.Has(HookPreDelete) {
+		post, err := docbase.GetPost(ctx, req.Domain, req.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := req.Hooks.Run(ctx, HookPreDelete, req.Domain, *post); err != nil {
+			return err
+		}
+	}
+	r, err := newRequest(ctx, http.MethodDelete, buildURL("teams", req.Domain, "posts", req.ID.String()), nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := doRequest(r, nil); err != nil {
+		return fmt.Errorf("failed to delete post(%d): %w", req.ID, err)
+	}
+	return nil
+}
+
+/***************************************
+ * Archive/Unarchive Post
+ ***************************************/
+
+type ArchivePostRequest struct {
+	Domain string
+	ID     docbase.PostID
+}
+
+func ArchivePost(ctx context.Context, req ArchivePostRequest) error {
+	return putArchive(ctx, req, "archive")
+}
+
+func UnarchivePost(ctx context.Context, req ArchivePostRequest) error {
+	return putArchive(ctx, req, "unarchive")
+}
+
+func putArchive(ctx context.Context, req ArchivePostRequest, action string) error {
+	log.Printf("%s post with req: %v", action, req)
+	r, err := newRequest(ctx, http.MethodPut, buildURL("teams", req.Domain, "posts", req.ID.String(), action), nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := doRequest(r, nil); err != nil {
+		return fmt.Errorf("failed to %s post(%d): %w", action, req.ID, err)
+	}
+	return nil
 }
 
 func marshal(v interface{}) string {
@@ -178,13 +353,43 @@ Showed {{len .Lines}} of {{.Total}}
 	}
 }
 
+// OutputPostRendered は、メモを端末向けに整形して表示する。
+// 本文は先頭の n 行のみを表示する (0 の場合は全て)。長い場合はページャで表示する。
+func OutputPostRendered(out *os.File, n int, style text.Style) PostHandler {
+	return func(ctx context.Context, post docbase.Post) error {
+		opt := text.RenderOptions{Style: style, Width: TerminalWidth(out)}
+		body := text.Dos2Unix(post.Body)
+		if n > 0 {
+			if lines := strings.Split(body, "\n"); n < len(lines) {
+				body = strings.Join(lines[:n], "\n")
+			}
+		}
+		sb := new(strings.Builder)
+		sb.WriteString(text.RenderMarkdown("# "+post.Title, opt))
+		meta := []string{fmt.Sprintf("%d", post.ID)}
+		for _, tag := range post.Tags {
+			meta = append(meta, "#"+tag.Name)
+		}
+		if post.User.Name != "" {
+			meta = append(meta, "@"+post.User.Name)
+		}
+		meta = append(meta, "updated: "+post.UpdatedAt)
+		if post.Draft {
+			meta = append(meta, "[draft]")
+		}
+		if post.Archived {
+			meta = append(meta, "[archived]")
+		}
+		sb.WriteString(strings.Join(meta, "  ") + "\n\n")
+		sb.WriteString(text.RenderMarkdown(body, opt))
+		return Page(out, sb.String())
+	}
+}
+
 func BuildPostCollectionHandler(withMeta bool) (PostCollectionHandler, error) {
-	const _tmplPostsList = `{{range .}}{{printf "%d\t%s" .ID (summary .)}}{{"\n"}}{{end}}`
-	tmplPostsList, err := template.New("list-posts").Funcs(template.FuncMap{
-		"summary": summarizePost,
-	}).Parse(_tmplPostsList)
-	if err != nil {
-		return nil, err
+	list := WriteTo(os.Stdout, FormatText)
+	if !withMeta {
+		return list, nil
 	}
 	const _tmplMetaData = `---
 Total: {{.Total}}
@@ -194,18 +399,9 @@ Total: {{.Total}}
 	if err != nil {
 		return nil, err
 	}
-	if withMeta {
-		return func(ctx context.Context, posts []docbase.Post, meta docbase.Meta) error {
-			err := tmplPostsList.Execute(os.Stdout, posts)
-			if err != nil {
-				return err
-			}
-			return tmplMetaData.Execute(os.Stdout, meta)
-		}, nil
-	}
-	return func(ctx context.Context, posts []docbase.Post, _ docbase.Meta) error {
-		return tmplPostsList.Execute(os.Stdout, posts)
-	}, nil
+	return Tee(list, func(ctx context.Context, _ []docbase.Post, meta docbase.Meta) error {
+		return tmplMetaData.Execute(os.Stdout, meta)
+	}), nil
 }
 
 // メモを要約した文字列を生成する
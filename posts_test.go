@@ -3,6 +3,8 @@ package docbasecli
 import (
 	"bytes"
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 	"time"
 
@@ -198,3 +200,39 @@ func Test_postSummary(t *testing.T) {
 		})
 	}
 }
+
+func TestArchivePost(t *testing.T) {
+	var got []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	ctx := context.Background()
+	req := ArchivePostRequest{Domain: "example", ID: 123}
+	if err := ArchivePost(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnarchivePost(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"PUT /teams/example/posts/123/archive",
+		"PUT /teams/example/posts/123/unarchive",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("requests mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestDeletePost(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/teams/example/posts/123" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	err := DeletePost(context.Background(), DeletePostRequest{Domain: "example", ID: 123})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+}
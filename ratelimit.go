@@ -0,0 +1,87 @@
+package docbasecli
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit は DocBase API のレートリミットの状態
+// (X-RateLimit-* レスポンスヘッダ) を表す
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+var (
+	rateLimitMu   sync.Mutex
+	lastRateLimit *RateLimit
+)
+
+// LastRateLimit は、直近の API 呼び出しで得られたレートリミットの状態を返す。
+// まだ得られていない場合は nil を返す。
+func LastRateLimit() *RateLimit {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	if lastRateLimit == nil {
+		return nil
+	}
+	rl := *lastRateLimit
+	return &rl
+}
+
+func recordRateLimit(h http.Header) {
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	lastRateLimit = &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}
+}
+
+// IsRateLimited は、err が API のレートリミット超過 (429) によるものかを判定する。
+// go-docbase の呼び出しは Transport を設定した場合のみ判定できる。
+func IsRateLimited(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+const maxRateLimitRetry = 5
+
+// RetryOnRateLimit は fn を実行し、レートリミット超過で失敗した場合は
+// リセット時刻まで (不明な場合は指数的に) 待機して再試行する。
+func RetryOnRateLimit(ctx context.Context, fn func() error) error {
+	wait := time.Second
+	for i := 0; ; i++ {
+		err := fn()
+		if !IsRateLimited(err) || i == maxRateLimitRetry {
+			return err
+		}
+		d := wait
+		if rl := LastRateLimit(); rl != nil && rl.Remaining == 0 && time.Until(rl.Reset) > 0 {
+			d = time.Until(rl.Reset)
+		}
+		log.Printf("rate limited, retry after %s", d)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+		}
+		wait *= 2
+	}
+}
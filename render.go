@@ -0,0 +1,292 @@
+package docbasecli
+
+// メモのローカルでの書き出し
+//
+// アカウントを持たない外部の人に渡せるよう、メモの本文 (Markdown) を
+// 単体で閲覧できる HTML もしくは PDF に変換する。
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+	"github.com/russross/blackfriday/v2"
+)
+
+// RenderFormat は、メモの書き出し形式
+type RenderFormat string
+
+const (
+	RenderHTML RenderFormat = "html"
+	RenderPDF  RenderFormat = "pdf"
+)
+
+// ParseRenderFormat は、文字列を RenderFormat に変換する。空文字は RenderHTML とみなす。
+func ParseRenderFormat(s string) (RenderFormat, error) {
+	switch f := RenderFormat(s); f {
+	case "":
+		return RenderHTML, nil
+	case RenderHTML, RenderPDF:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported format %q (html or pdf)", s)
+}
+
+// DefaultCSS は、HTML に埋め込むスタイルシート
+//
+//go:embed render.css
+var DefaultCSS string
+
+// markdownExtensions は、DocBase の Markdown に合わせた拡張
+const markdownExtensions = blackfriday.CommonExtensions | blackfriday.HardLineBreak
+
+type RenderRequest struct {
+	Domain string
+	Posts  []docbase.Post
+	Format RenderFormat
+
+	// Title 複数のメモをまとめる場合の文書のタイトル
+	Title string
+
+	// CSS HTML に埋め込むスタイルシート。省略した場合は DefaultCSS
+	CSS string
+
+	// Font PDF の本文に使う TrueType フォントのパス。
+	// 省略した場合は Helvetica を使うため、ラテン文字以外は出力できない。
+	Font string
+
+	// Embed 画像を取得して文書に埋め込む
+	Embed bool
+}
+
+// Render は、req.Posts を１つの文書にまとめて out に書き出す。
+// 複数のメモを指定した場合は、先頭に目次を付ける。
+func Render(ctx context.Context, out io.Writer, req RenderRequest) error {
+	log.Printf("render %d posts to %s", len(req.Posts), req.Format)
+	if len(req.Posts) == 0 {
+		return fmt.Errorf("no posts to render")
+	}
+	switch req.Format {
+	case RenderPDF:
+		return renderPDF(ctx, out, req)
+	case RenderHTML, "":
+		return renderHTML(ctx, out, req)
+	}
+	return fmt.Errorf("unsupported format %q", req.Format)
+}
+
+// documentTitle は、書き出す文書のタイトルを返す
+func documentTitle(req RenderRequest) string {
+	switch {
+	case req.Title != "":
+		return req.Title
+	case len(req.Posts) == 1:
+		return req.Posts[0].Title
+	}
+	return fmt.Sprintf("%d posts", len(req.Posts))
+}
+
+// postMeta は、メモの作成者・更新日時・タグを１行にまとめる
+func postMeta(post docbase.Post) string {
+	var meta []string
+	if post.User.Name != "" {
+		meta = append(meta, "@"+post.User.Name)
+	}
+	if post.UpdatedAt != "" {
+		meta = append(meta, "updated: "+post.UpdatedAt)
+	}
+	for _, tag := range post.Tags {
+		meta = append(meta, "#"+tag.Name)
+	}
+	return strings.Join(meta, "  ")
+}
+
+/***************************************
+ * HTML
+ ***************************************/
+
+var htmlTemplate = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+{{.CSS}}
+</style>
+</head>
+<body>
+{{- if gt (len .Articles) 1}}
+<nav class="toc">
+<h1>{{.Title}}</h1>
+<ol>
+{{- range .Articles}}
+<li><a href="#post-{{.ID}}">{{.Title}}</a></li>
+{{- end}}
+</ol>
+</nav>
+{{- end}}
+{{- range .Articles}}
+<article id="post-{{.ID}}">
+<header>
+<h1>{{.Title}}</h1>
+{{- if .Meta}}
+<p class="meta">{{.Meta}}</p>
+{{- end}}
+</header>
+{{.Body}}
+</article>
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlArticle struct {
+	ID    docbase.PostID
+	Title string
+	Meta  string
+	Body  template.HTML
+}
+
+func renderHTML(ctx context.Context, out io.Writer, req RenderRequest) error {
+	css := req.CSS
+	if css == "" {
+		css = DefaultCSS
+	}
+	data := struct {
+		Title    string
+		CSS      template.CSS
+		Articles []htmlArticle
+	}{Title: documentTitle(req), CSS: template.CSS(css)}
+
+	for _, post := range req.Posts {
+		body := MarkdownToHTML(post.Body)
+		if req.Embed {
+			body = embedImages(ctx, req.Domain, body)
+		}
+		data.Articles = append(data.Articles, htmlArticle{
+			ID:    post.ID,
+			Title: post.Title,
+			Meta:  postMeta(post),
+			Body:  template.HTML(body),
+		})
+	}
+	return htmlTemplate.Execute(out, data)
+}
+
+var (
+	taskListItem  = regexp.MustCompile(`<li>(<p>)?\[([ xX])\] `)
+	trailingBreak = regexp.MustCompile(`<br />\n(</li>|<ul>|<ol>)`)
+)
+
+// MarkdownToHTML は、Markdown を HTML に変換する。
+// タスクリストの "[ ]" と "[x]" はチェックボックスに置き換える。
+// 改行はそのまま改行として扱うが、リストの項目末尾の改行は取り除く。
+// mermaid や plantuml などのコードブロックは、そのままコードとして出力する。
+func MarkdownToHTML(md string) string {
+	b := blackfriday.Run([]byte(text.Dos2Unix(md)), blackfriday.WithExtensions(markdownExtensions))
+	s := trailingBreak.ReplaceAllString(string(b), "\n$1")
+	return taskListItem.ReplaceAllStringFunc(s, func(s string) string {
+		m := taskListItem.FindStringSubmatch(s)
+		checked := ""
+		if m[2] != " " {
+			checked = " checked"
+		}
+		return fmt.Sprintf(`<li class="task-list-item">%s<input type="checkbox" disabled%s> `, m[1], checked)
+	})
+}
+
+var imgSrc = regexp.MustCompile(`(<img[^>]* src=")([^"]+)(")`)
+
+// embedImages は、HTML 中の画像を data URI に置き換える。
+// 取得できなかった画像は元の URL のまま残す。
+func embedImages(ctx context.Context, domain, body string) string {
+	return imgSrc.ReplaceAllStringFunc(body, func(s string) string {
+		m := imgSrc.FindStringSubmatch(s)
+		src := html.UnescapeString(m[2])
+		if strings.HasPrefix(src, "data:") {
+			return s
+		}
+		b, err := fetchAttachment(ctx, domain, src)
+		if err != nil {
+			log.Printf("failed to embed image %q: %v", src, err)
+			return s
+		}
+		return m[1] + dataURI(b) + m[3]
+	})
+}
+
+func dataURI(b []byte) string {
+	return "data:" + http.DetectContentType(b) + ";base64," + base64.StdEncoding.EncodeToString(b)
+}
+
+// attachmentHost は、DocBase にアップロードされたファイルの配信元
+var attachmentHost = "image.docbase.io"
+
+// fetchAttachment は、src の内容を取得する。
+// DocBase にアップロードされたファイルは、閲覧権限が必要なため API 経由で取得する。
+func fetchAttachment(ctx context.Context, domain, src string) ([]byte, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == attachmentHost && strings.HasPrefix(u.Path, "/uploads/") {
+		req, err := newRequest(ctx, http.MethodGet, buildURL("teams", domain, "attachments", path.Base(u.Path)), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var b []byte
+		if err := doRequest(req, &b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url %q", src)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	log.Println(req.Method, req.URL)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if 300 <= resp.StatusCode {
+		return nil, fmt.Errorf("failed to get %q: %s", src, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// inlineText は、node 以下のテキストを連結して返す。段落や見出しの間は空白で区切る。
+func inlineText(node *blackfriday.Node) string {
+	buf := new(bytes.Buffer)
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		switch {
+		case entering && (n.Type == blackfriday.Text || n.Type == blackfriday.Code):
+			buf.Write(n.Literal)
+		case entering && (n.Type == blackfriday.Softbreak || n.Type == blackfriday.Hardbreak):
+			buf.WriteByte(' ')
+		case !entering && n != node && (n.Type == blackfriday.Paragraph || n.Type == blackfriday.Heading):
+			buf.WriteByte(' ')
+		}
+		return blackfriday.GoToNext
+	})
+	return strings.TrimSpace(buf.String())
+}
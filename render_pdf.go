@@ -0,0 +1,437 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/micheam/docbase-cli/text"
+	"github.com/russross/blackfriday/v2"
+)
+
+const (
+	pdfFontSize   = 10.5
+	pdfLineHeight = 5.5 // mm
+	pdfIndent     = 6.0 // mm
+)
+
+// pdfHeadingSizes は、見出しレベルごとの文字の大きさ
+var pdfHeadingSizes = []float64{20, 16, 13.5, 12, 11, 10.5}
+
+// pdfWriter は、Markdown の構文木を PDF に書き出す
+type pdfWriter struct {
+	ctx    context.Context
+	domain string
+	embed  bool
+
+	pdf    *fpdf.Fpdf
+	family string
+	mono   string
+	utf8   bool
+	tr     func(string) string
+
+	size   float64
+	style  string
+	margin float64
+	indent float64
+	link   string
+	items  []int // 入れ子になったリストの項目番号 (0 は番号なし)
+	quote  int
+}
+
+func renderPDF(ctx context.Context, out io.Writer, req RenderRequest) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	w := &pdfWriter{ctx: ctx, domain: req.Domain, embed: req.Embed, pdf: pdf,
+		family: "Helvetica", mono: "Courier", size: pdfFontSize, tr: func(s string) string { return s }}
+	if req.Font != "" {
+		b, err := ioutil.ReadFile(req.Font)
+		if err != nil {
+			return err
+		}
+		pdf.AddUTF8FontFromBytes("body", "", b)
+		if pdf.Err() {
+			return fmt.Errorf("failed to load font %q: %w", req.Font, pdf.Error())
+		}
+		w.family, w.mono, w.utf8 = "body", "body", true
+	} else {
+		if err := checkLatin(req); err != nil {
+			return err
+		}
+		w.tr = pdf.UnicodeTranslatorFromDescriptor("")
+	}
+	w.margin, _, _, _ = pdf.GetMargins()
+
+	title := documentTitle(req)
+	pdf.SetTitle(title, true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		w.setFont(w.family, "", 8)
+		pdf.CellFormat(0, 10, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	// 目次のリンク先は、各メモを書き出す時点で設定する
+	links := make([]int, len(req.Posts))
+	if len(req.Posts) > 1 {
+		pdf.AddPage()
+		w.setFont(w.family, "B", pdfHeadingSizes[0])
+		pdf.MultiCell(0, 10, w.tr(title), "", "L", false)
+		pdf.Ln(4)
+		w.setFont(w.family, "", pdfFontSize+1)
+		for i, post := range req.Posts {
+			links[i] = pdf.AddLink()
+			pdf.CellFormat(0, 8, w.tr(fmt.Sprintf("%d. %s", i+1, post.Title)), "", 1, "L", false, links[i], "")
+		}
+	}
+	for i, post := range req.Posts {
+		pdf.AddPage()
+		if len(req.Posts) > 1 {
+			pdf.SetLink(links[i], -1, -1)
+		}
+		pdf.Bookmark(post.Title, 0, -1)
+		w.setFont(w.family, "B", pdfHeadingSizes[0])
+		pdf.MultiCell(0, 10, w.tr(post.Title), "", "L", false)
+		if meta := postMeta(post); meta != "" {
+			pdf.SetTextColor(106, 115, 125)
+			w.setFont(w.family, "", 9)
+			pdf.MultiCell(0, 5, w.tr(meta), "", "L", false)
+			pdf.SetTextColor(0, 0, 0)
+		}
+		pdf.Ln(4)
+		w.setFont(w.family, "", pdfFontSize)
+
+		md := blackfriday.New(blackfriday.WithExtensions(markdownExtensions))
+		md.Parse([]byte(text.Dos2Unix(post.Body))).Walk(w.visit)
+		if pdf.Err() {
+			return pdf.Error()
+		}
+	}
+	return pdf.Output(out)
+}
+
+// checkLatin は、フォントを指定せずに出力できない文字が含まれていないかを調べる
+func checkLatin(req RenderRequest) error {
+	for _, post := range req.Posts {
+		for _, r := range post.Title + post.Body {
+			if r > 0xff && !strings.ContainsRune("‘’“”–—•…€™", r) {
+				return fmt.Errorf("post(%d) contains non-Latin character %q; specify TrueType font to render PDF", post.ID, r)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *pdfWriter) setFont(family, style string, size float64) {
+	if w.utf8 {
+		// 指定されたフォントは標準のスタイルしか登録していない
+		style = ""
+	}
+	w.pdf.SetFont(family, style, size)
+}
+
+func (w *pdfWriter) resetFont() {
+	w.setFont(w.family, w.style, w.size)
+}
+
+// resetColor は、文字色を引用の中かどうかに応じた色に戻す
+func (w *pdfWriter) resetColor() {
+	if w.quote > 0 {
+		w.pdf.SetTextColor(106, 115, 125)
+		return
+	}
+	w.pdf.SetTextColor(0, 0, 0)
+}
+
+func (w *pdfWriter) setIndent(indent float64) {
+	w.indent = indent
+	w.pdf.SetLeftMargin(w.margin + indent)
+	w.pdf.SetX(w.margin + indent)
+}
+
+func (w *pdfWriter) write(s string) {
+	if w.link != "" {
+		w.pdf.WriteLinkString(pdfLineHeight, w.tr(s), w.link)
+		return
+	}
+	w.pdf.Write(pdfLineHeight, w.tr(s))
+}
+
+func (w *pdfWriter) addStyle(s string, entering bool) {
+	if entering {
+		w.style += s
+	} else {
+		w.style = strings.Replace(w.style, s, "", 1)
+	}
+	w.resetFont()
+}
+
+func (w *pdfWriter) visit(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	pdf := w.pdf
+	switch node.Type {
+	case blackfriday.Heading:
+		level := node.HeadingData.Level
+		if entering {
+			pdf.Ln(pdfLineHeight / 2)
+			w.size = pdfHeadingSizes[clamp(level-1, 0, len(pdfHeadingSizes)-1)]
+			w.style = "B"
+			w.resetFont()
+			pdf.Bookmark(inlineText(node), 1, -1)
+			return blackfriday.GoToNext
+		}
+		pdf.Ln(w.size * 0.5)
+		w.size, w.style = pdfFontSize, ""
+		w.resetFont()
+		pdf.Ln(pdfLineHeight / 2)
+
+	case blackfriday.Paragraph:
+		if !entering {
+			pdf.Ln(pdfLineHeight)
+			if node.Parent.Type != blackfriday.Item || !node.Parent.ListData.Tight {
+				pdf.Ln(pdfLineHeight / 2)
+			}
+		}
+
+	case blackfriday.Text:
+		if entering {
+			w.write(string(node.Literal))
+		}
+	case blackfriday.Softbreak:
+		w.write(" ")
+	case blackfriday.Hardbreak:
+		if node.Next != nil {
+			pdf.Ln(pdfLineHeight)
+		}
+
+	case blackfriday.Emph:
+		w.addStyle("I", entering)
+	case blackfriday.Strong:
+		w.addStyle("B", entering)
+	case blackfriday.Del:
+		w.addStyle("S", entering)
+
+	case blackfriday.Code:
+		w.setFont(w.mono, "", w.size)
+		pdf.SetTextColor(199, 37, 78)
+		w.write(string(node.Literal))
+		w.resetColor()
+		w.resetFont()
+
+	case blackfriday.Link:
+		if entering {
+			w.link = string(node.LinkData.Destination)
+			pdf.SetTextColor(3, 102, 214)
+		} else {
+			w.link = ""
+			w.resetColor()
+		}
+
+	case blackfriday.Image:
+		if entering {
+			w.image(node)
+		}
+		return blackfriday.SkipChildren
+
+	case blackfriday.CodeBlock:
+		w.setFont(w.mono, "", pdfFontSize-1)
+		pdf.SetFillColor(246, 248, 250)
+		if info := strings.TrimSpace(string(node.CodeBlockData.Info)); info != "" {
+			pdf.SetTextColor(106, 115, 125)
+			pdf.CellFormat(0, pdfLineHeight, w.tr(info), "", 1, "L", true, 0, "")
+			w.resetColor()
+		}
+		code := strings.TrimRight(strings.ReplaceAll(string(node.Literal), "\t", "    "), "\n")
+		pdf.MultiCell(0, pdfLineHeight-0.5, w.tr(code), "", "L", true)
+		pdf.Ln(pdfLineHeight / 2)
+		w.resetFont()
+
+	case blackfriday.HTMLBlock:
+		pdf.MultiCell(0, pdfLineHeight, w.tr(string(node.Literal)), "", "L", false)
+	case blackfriday.HTMLSpan:
+		w.write(string(node.Literal))
+
+	case blackfriday.HorizontalRule:
+		pdf.Ln(pdfLineHeight / 2)
+		pageW, _ := pdf.GetPageSize()
+		_, _, right, _ := pdf.GetMargins()
+		pdf.SetDrawColor(200, 200, 200)
+		pdf.Line(w.margin+w.indent, pdf.GetY(), pageW-right, pdf.GetY())
+		pdf.SetDrawColor(0, 0, 0)
+		pdf.Ln(pdfLineHeight / 2)
+
+	case blackfriday.BlockQuote:
+		if entering {
+			w.quote++
+			w.setIndent(w.indent + pdfIndent)
+			w.resetColor()
+			return blackfriday.GoToNext
+		}
+		w.quote--
+		w.setIndent(w.indent - pdfIndent)
+		w.resetColor()
+
+	case blackfriday.List:
+		if entering {
+			n := 0
+			if node.ListData.ListFlags&blackfriday.ListTypeOrdered != 0 {
+				n = 1
+			}
+			w.items = append(w.items, n)
+			w.setIndent(w.indent + pdfIndent)
+			return blackfriday.GoToNext
+		}
+		w.items = w.items[:len(w.items)-1]
+		w.setIndent(w.indent - pdfIndent)
+		if len(w.items) == 0 {
+			pdf.Ln(pdfLineHeight / 2)
+		}
+
+	case blackfriday.Item:
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		if pdf.GetX() > w.margin+w.indent {
+			pdf.Ln(pdfLineHeight)
+		}
+		marker := "-"
+		if w.utf8 {
+			marker = "•"
+		}
+		if n := &w.items[len(w.items)-1]; *n > 0 {
+			marker = strconv.Itoa(*n) + "."
+			*n++
+		}
+		pdf.SetX(w.margin + w.indent - pdfIndent)
+		pdf.CellFormat(pdfIndent, pdfLineHeight, w.tr(marker), "", 0, "R", false, 0, "")
+		pdf.SetX(w.margin + w.indent)
+
+	case blackfriday.Table:
+		if entering {
+			w.table(node)
+		}
+		return blackfriday.SkipChildren
+	}
+	return blackfriday.GoToNext
+}
+
+// table は、表を罫線付きのセルとして書き出す。
+// 列幅は均等に割り当て、収まらない文字列は切り詰める。
+func (w *pdfWriter) table(node *blackfriday.Node) {
+	pdf := w.pdf
+	var rows [][]*blackfriday.Node
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch n.Type {
+		case blackfriday.TableRow:
+			rows = append(rows, nil)
+		case blackfriday.TableCell:
+			rows[len(rows)-1] = append(rows[len(rows)-1], n)
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return
+	}
+	pageW, _ := pdf.GetPageSize()
+	_, _, right, _ := pdf.GetMargins()
+	colW := (pageW - right - w.margin - w.indent) / float64(cols)
+
+	pdf.SetFillColor(246, 248, 250)
+	for _, row := range rows {
+		for _, cell := range row {
+			style := ""
+			if cell.TableCellData.IsHeader {
+				style = "B"
+			}
+			w.setFont(w.family, style, pdfFontSize-1)
+			align := "L"
+			switch cell.TableCellData.Align {
+			case blackfriday.TableAlignmentCenter:
+				align = "C"
+			case blackfriday.TableAlignmentRight:
+				align = "R"
+			}
+			s := w.tr(inlineText(cell))
+			for s != "" && pdf.GetStringWidth(s) > colW-2 {
+				s = s[:len(s)-1]
+				if w.utf8 {
+					s = strings.ToValidUTF8(s, "")
+				}
+			}
+			pdf.CellFormat(colW, pdfLineHeight+1, s, "1", 0, align, cell.TableCellData.IsHeader, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(pdfLineHeight / 2)
+	w.resetFont()
+}
+
+// image は、画像を本文の幅に収めて書き出す。
+// 埋め込まない場合や取得に失敗した場合は、代替テキストとリンクを書き出す。
+func (w *pdfWriter) image(node *blackfriday.Node) {
+	pdf := w.pdf
+	src := string(node.LinkData.Destination)
+	alt := inlineText(node)
+	if alt == "" {
+		alt = src
+	}
+	if !w.embed {
+		w.pdf.WriteLinkString(pdfLineHeight, w.tr("["+alt+"]"), src)
+		return
+	}
+	b, err := fetchAttachment(w.ctx, w.domain, src)
+	if err != nil {
+		log.Printf("failed to embed image %q: %v", src, err)
+		w.pdf.WriteLinkString(pdfLineHeight, w.tr("["+alt+"]"), src)
+		return
+	}
+	var typ string
+	switch http.DetectContentType(b) {
+	case "image/png":
+		typ = "PNG"
+	case "image/jpeg":
+		typ = "JPG"
+	case "image/gif":
+		typ = "GIF"
+	default:
+		log.Printf("unsupported image type %q", src)
+		w.pdf.WriteLinkString(pdfLineHeight, w.tr("["+alt+"]"), src)
+		return
+	}
+	if pdf.Err() {
+		return
+	}
+	opt := fpdf.ImageOptions{ImageType: typ, ReadDpi: true}
+	info := pdf.RegisterImageOptionsReader(src, opt, bytes.NewReader(b))
+	if info == nil || pdf.Err() {
+		// 壊れた画像などで文書全体を失敗させないよう、エラーを取り消してリンクにする
+		log.Printf("failed to embed image %q: %v", src, pdf.Error())
+		pdf.ClearError()
+		w.pdf.WriteLinkString(pdfLineHeight, w.tr("["+alt+"]"), src)
+		return
+	}
+	pageW, _ := pdf.GetPageSize()
+	_, _, right, _ := pdf.GetMargins()
+	width := info.Width()
+	if max := pageW - right - w.margin - w.indent; width > max {
+		width = max
+	}
+	if pdf.GetX() > w.margin+w.indent {
+		pdf.Ln(pdfLineHeight)
+	}
+	pdf.ImageOptions(src, w.margin+w.indent, pdf.GetY(), width, 0, true, opt, 0, "")
+}
@@ -0,0 +1,181 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want []string
+	}{
+		{
+			name: "task list",
+			md:   "- [ ] todo\r\n- [x] done\r\n",
+			want: []string{
+				"<li class=\"task-list-item\"><input type=\"checkbox\" disabled> todo\n</li>",
+				"<li class=\"task-list-item\"><input type=\"checkbox\" disabled checked> done\n</li>",
+			},
+		},
+		{
+			name: "table",
+			md:   "| a | b |\n|---|--:|\n| 1 | 2 |\n",
+			want: []string{"<table>", "<th>a</th>", `<td align="right">2</td>`},
+		},
+		{
+			name: "mermaid is left as code",
+			md:   "```mermaid\ngraph TD; A-->B\n```\n",
+			want: []string{`<pre><code class="language-mermaid">graph TD; A--&gt;B`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MarkdownToHTML(tt.md)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("want %q in:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nimage")
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/teams/example/attachments/abc.png" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(png)
+	}))
+	req := RenderRequest{
+		Domain: "example",
+		Format: RenderHTML,
+		Embed:  true,
+		Posts: []docbase.Post{
+			{ID: 1, Title: "First", Body: "![img](https://image.docbase.io/uploads/abc.png)"},
+			{ID: 2, Title: "Second <2>", Body: "body", User: docbase.User{Name: "micheam"}},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := Render(context.Background(), buf, req); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, w := range []string{
+		"<title>2 posts</title>",
+		`<li><a href="#post-1">First</a></li>`,
+		`<li><a href="#post-2">Second &lt;2&gt;</a></li>`,
+		`<article id="post-2">`,
+		`<p class="meta">@micheam</p>`,
+		`src="data:image/png;base64,` + base64.StdEncoding.EncodeToString(png) + `"`,
+		"li.task-list-item",
+	} {
+		if !strings.Contains(got, w) {
+			t.Errorf("want %q in:\n%s", w, got)
+		}
+	}
+}
+
+func TestRenderHTML_SinglePost(t *testing.T) {
+	req := RenderRequest{
+		Format: RenderHTML,
+		CSS:    "body { color: red; }",
+		Posts:  []docbase.Post{{ID: 1, Title: "Only", Body: "![img](https://example.com/missing.png)"}},
+	}
+	buf := new(bytes.Buffer)
+	if err := Render(context.Background(), buf, req); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if strings.Contains(got, `<nav class="toc">`) {
+		t.Errorf("single post should not have table of contents:\n%s", got)
+	}
+	for _, w := range []string{"<title>Only</title>", "body { color: red; }", `src="https://example.com/missing.png"`} {
+		if !strings.Contains(got, w) {
+			t.Errorf("want %q in:\n%s", w, got)
+		}
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	req := RenderRequest{
+		Format: RenderPDF,
+		Posts: []docbase.Post{
+			{ID: 1, Title: "First", Body: "# Heading\n\n- [x] **done**\n1. one\n\n> quote `code`\n\n| a | b |\n|---|---|\n| 1 | 2 |\n"},
+			{ID: 2, Title: "Second", Body: "```go\nfunc main() {}\n```\n\n---\n[link](https://example.com)"},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := Render(context.Background(), buf, req); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.HasPrefix(got, "%PDF-") {
+		t.Errorf("want PDF, but got %q", got[:10])
+	}
+}
+
+func TestRenderPDF_BrokenImage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// PNG のシグネチャのみで中身が壊れている画像
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\nbroken"))
+	}))
+	defer ts.Close()
+	req := RenderRequest{
+		Format: RenderPDF,
+		Embed:  true,
+		Posts:  []docbase.Post{{ID: 1, Title: "Image", Body: "![broken](" + ts.URL + "/broken.png)\n\nafter"}},
+	}
+	buf := new(bytes.Buffer)
+	if err := Render(context.Background(), buf, req); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.HasPrefix(got, "%PDF-") {
+		t.Errorf("want PDF, but got %q", got[:10])
+	}
+}
+
+func TestRenderPDF_NonLatin(t *testing.T) {
+	req := RenderRequest{
+		Format: RenderPDF,
+		Posts:  []docbase.Post{{ID: 1, Title: "日報", Body: "body"}},
+	}
+	err := Render(context.Background(), new(bytes.Buffer), req)
+	if err == nil || !strings.Contains(err.Error(), "TrueType font") {
+		t.Errorf("want error about font, but got %v", err)
+	}
+}
+
+func TestParseRenderFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RenderFormat
+		wantErr bool
+	}{
+		{in: "", want: RenderHTML},
+		{in: "html", want: RenderHTML},
+		{in: "pdf", want: RenderPDF},
+		{in: "docx", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRenderFormat(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRenderFormat(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("ParseRenderFormat(%q) mismatch (-want, +got):%s\n", tt.in, diff)
+		}
+	}
+}
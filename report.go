@@ -0,0 +1,189 @@
+package docbasecli
+
+// 長期間更新されていないメモの報告
+//
+// 報告は Markdown で出力し、そのまま DocBase のメモとして投稿できる。
+// DocBase API は最終更新者を提供していないため、このマシンでこのコマンドを使って
+// 編集した記録 (編集履歴) がある場合のみ、その編集者を LocalEditor として示す。
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/micheam/go-docbase"
+)
+
+// StalePost は、長期間更新されていないメモ
+type StalePost struct {
+	ID          docbase.PostID `json:"id"`
+	Title       string         `json:"title"`
+	URL         string         `json:"url"`
+	Author      string         `json:"author"`
+	LocalEditor string         `json:"local_editor"` // 編集履歴に記録された最後の編集者 (記録がなければ空)
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Tags        []string       `json:"tags"`
+}
+
+// StaleGroup は、作成者もしくはタグごとにまとめたメモ
+type StaleGroup struct {
+	Name  string      `json:"name"`
+	Posts []StalePost `json:"posts"`
+}
+
+// StaleReport は、Before より前から更新されていないメモの報告
+type StaleReport struct {
+	Before   time.Time    `json:"before"`
+	Posts    []StalePost  `json:"posts"` // 更新日時の古い順
+	ByAuthor []StaleGroup `json:"by_author"`
+	ByTag    []StaleGroup `json:"by_tag"`
+}
+
+type StaleReportHandler func(ctx context.Context, report StaleReport) error
+
+// noTagGroup は、タグの付いていないメモをまとめるグループの名前
+const noTagGroup = "(no tags)"
+
+type StaleReportRequest struct {
+	Domain string
+	Query  string
+	Before time.Time
+}
+
+// ReportStale は、req.Query に一致するメモのうち req.Before 以降に更新されていないものの報告を handle に渡す
+func ReportStale(ctx context.Context, req StaleReportRequest, handle StaleReportHandler) error {
+	log.Printf("report stale posts with req: %v", req)
+	posts, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &req.Query})
+	if err != nil {
+		return err
+	}
+	return handle(ctx, BuildStaleReport(req.Domain, posts, req.Before))
+}
+
+// BuildStaleReport は、posts のうち before 以降に更新されていないものを作成者・タグごとにまとめる。
+// LocalEditor は、このコマンドの編集履歴に記録された最後の編集者とする。
+func BuildStaleReport(domain string, posts []docbase.Post, before time.Time) StaleReport {
+	report := StaleReport{Before: before, Posts: []StalePost{}, ByAuthor: []StaleGroup{}, ByTag: []StaleGroup{}}
+	filter := PostFilter{UpdatedBefore: before}
+	for _, post := range posts {
+		if !filter.Match(post) {
+			continue
+		}
+		p := StalePost{
+			ID:        post.ID,
+			Title:     post.Title,
+			URL:       post.URL,
+			Author:    post.User.Name,
+			UpdatedAt: postTime(post.UpdatedAt),
+			Tags:      []string{},
+		}
+		for _, tag := range post.Tags {
+			p.Tags = append(p.Tags, tag.Name)
+		}
+		if revs, err := LoadRevisions(domain, post.ID); err == nil {
+			for i := len(revs) - 1; i >= 0; i-- {
+				if revs[i].Editor != "" {
+					p.LocalEditor = revs[i].Editor
+					break
+				}
+			}
+		}
+		report.Posts = append(report.Posts, p)
+	}
+	sort.SliceStable(report.Posts, func(i, j int) bool { return report.Posts[i].UpdatedAt.Before(report.Posts[j].UpdatedAt) })
+
+	byAuthor := map[string][]StalePost{}
+	byTag := map[string][]StalePost{}
+	for _, p := range report.Posts {
+		byAuthor[p.Author] = append(byAuthor[p.Author], p)
+		if len(p.Tags) == 0 {
+			byTag[noTagGroup] = append(byTag[noTagGroup], p)
+		}
+		for _, tag := range p.Tags {
+			byTag[tag] = append(byTag[tag], p)
+		}
+	}
+	report.ByAuthor = staleGroups(byAuthor)
+	report.ByTag = staleGroups(byTag)
+	return report
+}
+
+// staleGroups は、件数の多い順 (同数の場合は名前順) にグループを並べる
+func staleGroups(m map[string][]StalePost) []StaleGroup {
+	groups := make([]StaleGroup, 0, len(m))
+	for name, posts := range m {
+		groups = append(groups, StaleGroup{Name: name, Posts: posts})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Posts) != len(groups[j].Posts) {
+			return len(groups[i].Posts) > len(groups[j].Posts)
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// Markdown は、報告を DocBase に投稿できる Markdown にする
+func (r StaleReport) Markdown() string {
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "%d posts have not been updated since %s.\n", len(r.Posts), r.Before.Format("2006-01-02"))
+	if len(r.Posts) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\n## By author\n\n| Author | Posts |\n|---|---:|\n")
+	for _, g := range r.ByAuthor {
+		fmt.Fprintf(sb, "| %s | %d |\n", markdownCell(g.Name), len(g.Posts))
+	}
+	sb.WriteString("\n## By tag\n\n| Tag | Posts |\n|---|---:|\n")
+	for _, g := range r.ByTag {
+		fmt.Fprintf(sb, "| %s | %d |\n", markdownCell(g.Name), len(g.Posts))
+	}
+
+	sb.WriteString("\n## Posts\n")
+	for _, g := range r.ByAuthor {
+		fmt.Fprintf(sb, "\n### %s (%d)\n\n", g.Name, len(g.Posts))
+		sb.WriteString("| Post | Last updated | Last editor (local history) | Tags |\n|---|---|---|---|\n")
+		for _, p := range g.Posts {
+			tags := make([]string, len(p.Tags))
+			for i, tag := range p.Tags {
+				tags[i] = "#" + tag
+			}
+			editor := p.LocalEditor
+			if editor == "" {
+				editor = "-"
+			}
+			fmt.Fprintf(sb, "| [%s](%s) | %s | %s | %s |\n", markdownCell(p.Title), p.URL,
+				p.UpdatedAt.Format("2006-01-02"), markdownCell(editor), markdownCell(strings.Join(tags, " ")))
+		}
+	}
+	return sb.String()
+}
+
+// markdownCell は、表のセルに書けるよう | と改行をエスケープする
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// OutputStaleReport は、報告を format で指定された形式で出力する。text の場合は Markdown で出力する。
+func OutputStaleReport(out io.Writer, format Format) StaleReportHandler {
+	return func(ctx context.Context, r StaleReport) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, r)
+		case FormatCSV:
+			records := make([][]string, 0, len(r.Posts))
+			for _, p := range r.Posts {
+				records = append(records, []string{p.ID.String(), p.Title, p.Author, p.LocalEditor,
+					p.UpdatedAt.Format(time.RFC3339), strings.Join(p.Tags, " "), p.URL})
+			}
+			return writeCSV(out, []string{"id", "title", "author", "local_editor", "updated_at", "tags", "url"}, records)
+		}
+		_, err := io.WriteString(out, r.Markdown())
+		return err
+	}
+}
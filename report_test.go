@@ -0,0 +1,110 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestBuildStaleReport(t *testing.T) {
+	t.Setenv("DOCBASE_CACHE_DIR", t.TempDir())
+	if _, err := RecordRevision("example", docbase.Post{ID: 2, Title: "Deploy", Body: "v1"}, "bob", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	posts := []docbase.Post{
+		{ID: 1, Title: "Onboarding", URL: "https://example.docbase.io/posts/1", User: docbase.User{Name: "alice"},
+			UpdatedAt: "2020-01-10T00:00:00Z", Tags: []docbase.Tag{{Name: "handbook"}}},
+		{ID: 2, Title: "Deploy | Rollback", URL: "https://example.docbase.io/posts/2", User: docbase.User{Name: "alice"},
+			UpdatedAt: "2019-06-01T00:00:00Z", Tags: []docbase.Tag{{Name: "handbook"}, {Name: "ops"}}},
+		{ID: 3, Title: "Fresh", User: docbase.User{Name: "carol"}, UpdatedAt: "2021-04-01T00:00:00Z"},
+		{ID: 4, Title: "Memo", URL: "https://example.docbase.io/posts/4", User: docbase.User{Name: "carol"},
+			UpdatedAt: "2020-02-01T00:00:00Z"},
+	}
+	before := time.Date(2020, 4, 16, 0, 0, 0, 0, time.UTC)
+	got := BuildStaleReport("example", posts, before)
+
+	onboarding := StalePost{ID: 1, Title: "Onboarding", URL: "https://example.docbase.io/posts/1", Author: "alice",
+		UpdatedAt: time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC), Tags: []string{"handbook"}}
+	deploy := StalePost{ID: 2, Title: "Deploy | Rollback", URL: "https://example.docbase.io/posts/2", Author: "alice", LocalEditor: "bob",
+		UpdatedAt: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC), Tags: []string{"handbook", "ops"}}
+	memo := StalePost{ID: 4, Title: "Memo", URL: "https://example.docbase.io/posts/4", Author: "carol",
+		UpdatedAt: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), Tags: []string{}}
+	want := StaleReport{
+		Before: before,
+		Posts:  []StalePost{deploy, onboarding, memo},
+		ByAuthor: []StaleGroup{
+			{Name: "alice", Posts: []StalePost{deploy, onboarding}},
+			{Name: "carol", Posts: []StalePost{memo}},
+		},
+		ByTag: []StaleGroup{
+			{Name: "handbook", Posts: []StalePost{deploy, onboarding}},
+			{Name: "(no tags)", Posts: []StalePost{memo}},
+			{Name: "ops", Posts: []StalePost{deploy}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("BuildStaleReport mismatch (-want, +got):%s\n", diff)
+	}
+
+	wantMarkdown := `3 posts have not been updated since 2020-04-16.
+
+## By author
+
+| Author | Posts |
+|---|---:|
+| alice | 2 |
+| carol | 1 |
+
+## By tag
+
+| Tag | Posts |
+|---|---:|
+| handbook | 2 |
+| (no tags) | 1 |
+| ops | 1 |
+
+## Posts
+
+### alice (2)
+
+| Post | Last updated | Last editor (local history) | Tags |
+|---|---|---|---|
+| [Deploy \| Rollback](https://example.docbase.io/posts/2) | 2019-06-01 | bob | #handbook #ops |
+| [Onboarding](https://example.docbase.io/posts/1) | 2020-01-10 | - | #handbook |
+
+### carol (1)
+
+| Post | Last updated | Last editor (local history) | Tags |
+|---|---|---|---|
+| [Memo](https://example.docbase.io/posts/4) | 2020-02-01 | - |  |
+`
+	if diff := cmp.Diff(wantMarkdown, got.Markdown()); diff != "" {
+		t.Errorf("Markdown mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestOutputStaleReport(t *testing.T) {
+	r := StaleReport{Before: time.Date(2020, 4, 16, 0, 0, 0, 0, time.UTC), Posts: []StalePost{
+		{ID: 1, Title: "Onboarding", Author: "alice", LocalEditor: "bob", UpdatedAt: time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC), Tags: []string{"a", "b"}},
+	}}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatCSV, "id,title,author,local_editor,updated_at,tags,url\n1,Onboarding,alice,bob,2020-01-10T00:00:00Z,a b,\n"},
+		{FormatText, r.Markdown()},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		if err := OutputStaleReport(buf, tt.format)(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+			t.Errorf("%s output mismatch (-want, +got):%s\n", tt.format, diff)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package docbasecli
+
+// 秘密情報・個人情報の検出
+//
+// アクセスキーやトークン、メールアドレスなどがメモに貼り付けられたまま
+// アップロードされることを防ぐ。既存のメモの監査にも使う。
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+)
+
+// SecretDetector は、秘密情報を検出する規則
+type SecretDetector struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// DefaultSecretDetectors は、組み込みの検出器
+var DefaultSecretDetectors = []SecretDetector{
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}?[:=]\s*["']?[A-Za-z0-9/+]{40}\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"docbase-token", regexp.MustCompile(`(?i)(?:x-docbasetoken|docbase_?token)["']?\s*[:=]\s*["']?[A-Za-z0-9_-]{16,}`)},
+	{"private-key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`)},
+	{"phone", regexp.MustCompile(`(?:\+\d{1,3}[- ]?\d{1,4}|\b0\d{1,4})-\d{1,4}-\d{3,4}\b`)},
+}
+
+// exampleEmail は、例示用のドメイン (RFC 2606) のメールアドレス
+var exampleEmail = regexp.MustCompile(`(?i)@(?:[a-z0-9-]+\.)*example\.(?:com|net|org)$`)
+
+// SecretConfig は、秘密情報の検出の設定
+type SecretConfig struct {
+	// Disable 無効にする検出器の名前 (e.g. ["email", "phone"])
+	Disable []string
+
+	// Patterns 追加で検出する正規表現 (名前 → 正規表現)
+	Patterns map[string]string
+
+	// Allowlist 検出しない文字列を１行に１つ記述したファイルのパス
+	// 省略した場合は ConfigDir()/secrets-allowlist.txt (存在する場合のみ)
+	Allowlist string
+}
+
+// SecretFinding は、検出した秘密情報。
+// 出力から再び漏れないよう、Match は伏せ字にしたものを保持する。
+type SecretFinding struct {
+	Rule  string `json:"rule"`
+	Line  int    `json:"line"` // 1 始まり
+	Match string `json:"match"`
+}
+
+func (f SecretFinding) String() string {
+	return fmt.Sprintf("%d: [%s] %s", f.Line, f.Rule, f.Match)
+}
+
+// SecretError は、アップロードしようとした本文に秘密情報が含まれていたことを表す
+type SecretError struct {
+	Findings []SecretFinding
+}
+
+func (e *SecretError) Error() string {
+	return fmt.Sprintf("%d possible secrets found in body", len(e.Findings))
+}
+
+// SecretScanner は、本文に含まれる秘密情報を検出する
+type SecretScanner struct {
+	detectors []SecretDetector
+	allow     map[string]bool
+
+	// Confirm 指定した場合、アップロード前の検査で秘密情報が見つかると呼ばれる。
+	// nil を返した場合はそのままアップロードする。
+	Confirm func(err *SecretError) error
+}
+
+// NewSecretScanner は、cfg に従って組み込みの検出器と追加の正規表現を組み合わせた SecretScanner を返す
+func NewSecretScanner(cfg SecretConfig) (*SecretScanner, error) {
+	disabled := map[string]bool{}
+	for _, name := range cfg.Disable {
+		disabled[name] = true
+	}
+	s := &SecretScanner{allow: map[string]bool{}}
+	for _, d := range DefaultSecretDetectors {
+		if !disabled[d.Name] {
+			s.detectors = append(s.detectors, d)
+		}
+	}
+	names := make([]string, 0, len(cfg.Patterns))
+	for name := range cfg.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		re, err := regexp.Compile(cfg.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("illegal secret pattern %q: %w", name, err)
+		}
+		if !disabled[name] {
+			s.detectors = append(s.detectors, SecretDetector{Name: name, Pattern: re})
+		}
+	}
+
+	path, required := cfg.Allowlist, true
+	if path == "" {
+		dir, err := ConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		path, required = filepath.Join(dir, "secrets-allowlist.txt"), false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read allowlist: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if err := s.readAllowlist(f); err != nil {
+		return nil, fmt.Errorf("failed to read allowlist %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// readAllowlist は、許可する文字列を読み込む。空行と # で始まる行は無視する。
+func (s *SecretScanner) readAllowlist(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			s.allow[line] = true
+		}
+	}
+	return sc.Err()
+}
+
+// Scan は、body に含まれる秘密情報を行順に返す
+func (s *SecretScanner) Scan(body string) []SecretFinding {
+	var found []SecretFinding
+	for i, line := range strings.Split(text.Dos2Unix(body), "\n") {
+		for _, d := range s.detectors {
+			for _, m := range d.Pattern.FindAllString(line, -1) {
+				if s.allow[m] || (d.Name == "email" && exampleEmail.MatchString(m)) {
+					continue
+				}
+				found = append(found, SecretFinding{Rule: d.Name, Line: i + 1, Match: maskSecret(m)})
+			}
+		}
+	}
+	return found
+}
+
+// Check は、body に秘密情報が含まれる場合に *SecretError を返す
+func (s *SecretScanner) Check(body string) error {
+	if found := s.Scan(body); len(found) > 0 {
+		return &SecretError{Findings: found}
+	}
+	return nil
+}
+
+// maskSecret は、先頭の４文字を残して伏せ字にする
+func maskSecret(s string) string {
+	r := []rune(s)
+	if len(r) <= 4 {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:4]) + strings.Repeat("*", len(r)-4)
+}
+
+/***************************************
+ * Scan Posts
+ ***************************************/
+
+// SecretReport は、メモ１件に含まれる秘密情報
+type SecretReport struct {
+	ID       docbase.PostID  `json:"id"`
+	Title    string          `json:"title"`
+	URL      string          `json:"url"`
+	Findings []SecretFinding `json:"findings"`
+}
+
+type SecretReportHandler func(ctx context.Context, reports []SecretReport) error
+
+type ScanPostsRequest struct {
+	Domain  string
+	Query   string
+	Scanner *SecretScanner
+}
+
+// ScanPosts は、req.Query に一致するメモのうち秘密情報を含むものを handle に渡す
+func ScanPosts(ctx context.Context, req ScanPostsRequest, handle SecretReportHandler) error {
+	log.Printf("scan posts with query: %q", req.Query)
+	posts, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &req.Query})
+	if err != nil {
+		return err
+	}
+	return handle(ctx, ScanPostBodies(req.Scanner, posts))
+}
+
+// ScanPostBodies は、posts のうち秘密情報を含むものの検出結果を返す
+func ScanPostBodies(scanner *SecretScanner, posts []docbase.Post) []SecretReport {
+	reports := []SecretReport{}
+	for _, post := range posts {
+		if found := scanner.Scan(post.Body); len(found) > 0 {
+			reports = append(reports, SecretReport{ID: post.ID, Title: post.Title, URL: post.URL, Findings: found})
+		}
+	}
+	return reports
+}
+
+// OutputSecretReports は、検出結果を format で指定された形式で出力する
+func OutputSecretReports(out io.Writer, format Format) SecretReportHandler {
+	return func(ctx context.Context, reports []SecretReport) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, reports)
+		case FormatCSV:
+			var records [][]string
+			for _, r := range reports {
+				for _, f := range r.Findings {
+					records = append(records, []string{r.ID.String(), r.Title, strconv.Itoa(f.Line), f.Rule, f.Match})
+				}
+			}
+			return writeCSV(out, []string{"id", "title", "line", "rule", "match"}, records)
+		}
+		for _, r := range reports {
+			if _, err := fmt.Fprintf(out, "%d\t%s\n", r.ID, r.Title); err != nil {
+				return err
+			}
+			for _, f := range r.Findings {
+				if _, err := fmt.Fprintf(out, "\t%d\t%s\t%s\n", f.Line, f.Rule, f.Match); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
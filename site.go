@@ -0,0 +1,466 @@
+package docbasecli
+
+// 静的サイトの生成
+//
+// 検索条件に一致するメモを、アカウントがなくても閲覧できる HTML の集合として書き出す。
+// 書き出したメモの更新日時とビルドの設定を Out/.docbase-site.json に記録し、
+// 次回のビルドでは更新されたメモのページだけを書き直す。設定が変わった場合はすべて書き直す。
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+	"github.com/russross/blackfriday/v2"
+)
+
+// siteManifestName は、前回のビルドの内容を記録するファイルの名前
+const siteManifestName = ".docbase-site.json"
+
+//go:embed site.js
+var siteSearchJS string
+
+type SiteBuildRequest struct {
+	Domain string
+	Query  string
+	Out    string
+
+	// Title サイトのタイトル。省略した場合は Query
+	Title string
+
+	// CSS サイトのスタイルシート。省略した場合は DefaultCSS
+	CSS string
+
+	// Embed 画像を取得してページに埋め込む
+	Embed bool
+
+	// Full 前回のビルド結果を無視して、すべてのページを書き直す
+	Full bool
+}
+
+// SiteBuildResult は、サイトのビルド結果
+type SiteBuildResult struct {
+	Out     string
+	Posts   int
+	Updated []docbase.PostID
+	Removed []docbase.PostID
+}
+
+type SiteBuildHandler func(ctx context.Context, result SiteBuildResult) error
+
+// siteManifest は、前回のビルドの設定と、書き出したメモとその更新日時
+type siteManifest struct {
+	Query string                    `json:"query"`
+	Title string                    `json:"title"`
+	CSS   string                    `json:"css"` // スタイルシートの SHA-1
+	Embed bool                      `json:"embed"`
+	Posts map[docbase.PostID]string `json:"posts"`
+}
+
+// sameBuild は、m と o が同じ設定のビルドかを判定する
+func (m siteManifest) sameBuild(o siteManifest) bool {
+	return m.Query == o.Query && m.Title == o.Title && m.CSS == o.CSS && m.Embed == o.Embed
+}
+
+// BuildSite は、req.Query に一致するメモを取得してサイトを書き出す
+func BuildSite(ctx context.Context, req SiteBuildRequest, handle SiteBuildHandler) error {
+	log.Printf("build site with req: %v", req)
+	posts, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &req.Query})
+	if err != nil {
+		return err
+	}
+	result, err := WriteSite(ctx, req, posts)
+	if err != nil {
+		return err
+	}
+	return handle(ctx, *result)
+}
+
+// WriteSite は、posts をサイトとして req.Out に書き出す。
+// 以下のページを生成する。
+//
+//	index.html          すべてのメモの一覧と検索
+//	posts/ID.html       メモ
+//	tags/NAME.html      タグごとのメモの一覧
+//	groups/NAME.html    グループごとのメモの一覧
+func WriteSite(ctx context.Context, req SiteBuildRequest, posts []docbase.Post) (*SiteBuildResult, error) {
+	if req.Title == "" {
+		req.Title = req.Query
+	}
+	posts = append([]docbase.Post{}, posts...)
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].UpdatedAt > posts[j].UpdatedAt })
+
+	css := sha1.Sum([]byte(req.CSS))
+	next := siteManifest{
+		Query: req.Query,
+		Title: req.Title,
+		CSS:   hex.EncodeToString(css[:]),
+		Embed: req.Embed,
+		Posts: map[docbase.PostID]string{},
+	}
+	// 削除するページを求めるため、すべて書き直す場合も前回のメモの一覧は使う
+	prev := loadSiteManifest(req.Out)
+	rebuild := req.Full || !prev.sameBuild(next)
+	inSite := map[docbase.PostID]bool{}
+	for _, post := range posts {
+		inSite[post.ID] = true
+		next.Posts[post.ID] = post.UpdatedAt
+	}
+	// サイトに含まれるかどうかが変わったメモ。これらへのリンクを持つページは書き直す
+	changed := map[docbase.PostID]bool{}
+	for id := range prev.Posts {
+		if !inSite[id] {
+			changed[id] = true
+		}
+	}
+	for id := range inSite {
+		if _, ok := prev.Posts[id]; !ok {
+			changed[id] = true
+		}
+	}
+
+	result := &SiteBuildResult{Out: req.Out, Posts: len(posts)}
+	site := &siteWriter{
+		req:         req,
+		inSite:      inSite,
+		linkPattern: regexp.MustCompile(postLinkPattern(req.Domain)),
+		hrefPattern: regexp.MustCompile(`href="` + postLinkPattern(req.Domain) + `"`),
+	}
+	for _, post := range posts {
+		path := filepath.Join(req.Out, "posts", post.ID.String()+".html")
+		if !rebuild && prev.Posts[post.ID] == post.UpdatedAt && !site.linksTo(post, changed) && fileExists(path) {
+			continue
+		}
+		if err := writeFileFunc(path, func(w io.Writer) error { return site.writePost(ctx, w, post) }); err != nil {
+			return nil, err
+		}
+		result.Updated = append(result.Updated, post.ID)
+	}
+	for id := range prev.Posts {
+		if inSite[id] {
+			continue
+		}
+		path := filepath.Join(req.Out, "posts", id.String()+".html")
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		result.Removed = append(result.Removed, id)
+	}
+	sort.Slice(result.Removed, func(i, j int) bool { return result.Removed[i] < result.Removed[j] })
+
+	if err := site.writeIndexes(posts); err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(req.Out, siteManifestName), b, 0o644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func loadSiteManifest(dir string) siteManifest {
+	m := siteManifest{Posts: map[docbase.PostID]string{}}
+	b, err := ioutil.ReadFile(filepath.Join(dir, siteManifestName))
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		log.Printf("ignore broken site manifest: %v", err)
+		return siteManifest{Posts: map[docbase.PostID]string{}}
+	}
+	if m.Posts == nil {
+		m.Posts = map[docbase.PostID]string{}
+	}
+	return m
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// writeFileFunc は、path のディレクトリを作成して write の出力を書き込む
+func writeFileFunc(path string, write func(w io.Writer) error) error {
+	buf := new(bytes.Buffer)
+	if err := write(buf); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// postLinkPattern は、domain のメモへのリンクに一致する正規表現を返す
+func postLinkPattern(domain string) string {
+	return `https://` + regexp.QuoteMeta(domain) + `\.docbase\.io/posts/(\d+)(#[^"\s)]*)?`
+}
+
+// siteSlug は、タグやグループの名前をファイル名に使える文字列にする
+func siteSlug(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|# `, r) {
+			return '-'
+		}
+		return r
+	}, name)
+}
+
+type siteWriter struct {
+	req         SiteBuildRequest
+	inSite      map[docbase.PostID]bool
+	linkPattern *regexp.Regexp // 本文中のメモへのリンク
+	hrefPattern *regexp.Regexp // HTML 中のメモへのリンク
+}
+
+// linksTo は、post の本文が ids のいずれかのメモにリンクしているかを判定する
+func (s *siteWriter) linksTo(post docbase.Post, ids map[docbase.PostID]bool) bool {
+	for _, m := range s.linkPattern.FindAllStringSubmatch(post.Body, -1) {
+		if id, err := docbase.ParsePostID(m[1]); err == nil && ids[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteLinks は、サイトに含まれるメモへのリンクをローカルのページへのリンクに書き換える
+func (s *siteWriter) rewriteLinks(body string) string {
+	return s.hrefPattern.ReplaceAllStringFunc(body, func(href string) string {
+		m := s.hrefPattern.FindStringSubmatch(href)
+		id, err := docbase.ParsePostID(m[1])
+		if err != nil || !s.inSite[id] {
+			return href
+		}
+		return `href="` + id.String() + ".html" + m[2] + `"`
+	})
+}
+
+// siteEntry は、一覧ページに載せるタグやグループ
+type siteEntry struct {
+	Name  string
+	Slug  string
+	Count int
+}
+
+type sitePage struct {
+	Site   string
+	Title  string
+	Root   string
+	Meta   string
+	Body   template.HTML
+	Posts  []docbase.Post
+	Tags   []siteEntry
+	Groups []siteEntry
+	Search bool
+}
+
+func (s *siteWriter) writePost(ctx context.Context, w io.Writer, post docbase.Post) error {
+	body := s.rewriteLinks(MarkdownToHTML(post.Body))
+	if s.req.Embed {
+		body = embedImages(ctx, s.req.Domain, body)
+	}
+	page := sitePage{
+		Site:  s.req.Title,
+		Title: post.Title,
+		Root:  "../",
+		Meta:  postMeta(post),
+		Body:  template.HTML(body),
+	}
+	for _, tag := range post.Tags {
+		page.Tags = append(page.Tags, siteEntry{Name: tag.Name, Slug: siteSlug(tag.Name)})
+	}
+	for _, name := range PostGroupNames(post) {
+		page.Groups = append(page.Groups, siteEntry{Name: name, Slug: siteSlug(name)})
+	}
+	return siteTemplate.ExecuteTemplate(w, "post", page)
+}
+
+// writeIndexes は、トップページ・タグとグループごとの一覧・検索用の索引を書き出す
+func (s *siteWriter) writeIndexes(posts []docbase.Post) error {
+	out := s.req.Out
+	byTag := map[string][]docbase.Post{}
+	byGroup := map[string][]docbase.Post{}
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			byTag[tag.Name] = append(byTag[tag.Name], post)
+		}
+		for _, name := range PostGroupNames(post) {
+			byGroup[name] = append(byGroup[name], post)
+		}
+	}
+	tags, groups := siteEntries(byTag), siteEntries(byGroup)
+
+	for dir, entries := range map[string][]siteEntry{"tags": tags, "groups": groups} {
+		// 使われなくなったタグやグループのページを残さないよう作り直す
+		if err := os.RemoveAll(filepath.Join(out, dir)); err != nil {
+			return err
+		}
+		index := byTag
+		if dir == "groups" {
+			index = byGroup
+		}
+		for _, e := range entries {
+			page := sitePage{Site: s.req.Title, Title: e.Name, Root: "../", Posts: index[e.Name]}
+			path := filepath.Join(out, dir, e.Slug+".html")
+			if err := writeFileFunc(path, func(w io.Writer) error { return siteTemplate.ExecuteTemplate(w, "list", page) }); err != nil {
+				return err
+			}
+		}
+	}
+
+	page := sitePage{Site: s.req.Title, Title: s.req.Title, Posts: posts, Tags: tags, Groups: groups, Search: true}
+	if err := writeFileFunc(filepath.Join(out, "index.html"), func(w io.Writer) error {
+		return siteTemplate.ExecuteTemplate(w, "list", page)
+	}); err != nil {
+		return err
+	}
+	css := s.req.CSS
+	if css == "" {
+		css = DefaultCSS
+	}
+	if err := writeFileFunc(filepath.Join(out, "style.css"), func(w io.Writer) error {
+		_, err := io.WriteString(w, css)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := writeFileFunc(filepath.Join(out, "search.js"), func(w io.Writer) error {
+		_, err := io.WriteString(w, siteSearchJS)
+		return err
+	}); err != nil {
+		return err
+	}
+	return writeFileFunc(filepath.Join(out, "search-index.js"), func(w io.Writer) error {
+		return writeSearchIndex(w, posts)
+	})
+}
+
+func siteEntries(index map[string][]docbase.Post) []siteEntry {
+	entries := make([]siteEntry, 0, len(index))
+	for name, posts := range index {
+		entries = append(entries, siteEntry{Name: name, Slug: siteSlug(name), Count: len(posts)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// searchEntry は、クライアント側の検索に使う索引の１件
+type searchEntry struct {
+	ID    docbase.PostID `json:"id"`
+	Title string         `json:"title"`
+	Tags  []string       `json:"tags"`
+	Text  string         `json:"text"`
+}
+
+// writeSearchIndex は、検索用の索引をスクリプトとして書き出す。
+// file:// で開いた場合も読み込めるよう、JSON ではなく変数の定義として出力する。
+func writeSearchIndex(w io.Writer, posts []docbase.Post) error {
+	entries := make([]searchEntry, len(posts))
+	for i, post := range posts {
+		doc := blackfriday.New(blackfriday.WithExtensions(markdownExtensions)).Parse([]byte(text.Dos2Unix(post.Body)))
+		entries[i] = searchEntry{ID: post.ID, Title: post.Title, Tags: []string{}, Text: inlineText(doc)}
+		for _, tag := range post.Tags {
+			entries[i].Tags = append(entries[i].Tags, tag.Name)
+		}
+	}
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "var SEARCH_INDEX = %s;\n", b)
+	return err
+}
+
+var siteTemplate = template.Must(template.New("site").Funcs(template.FuncMap{
+	"postPath": func(root string, id docbase.PostID) string { return root + "posts/" + id.String() + ".html" },
+}).Parse(`
+{{- define "head" -}}
+<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if ne .Title .Site}}{{.Title}} - {{end}}{{.Site}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<nav class="site"><a href="{{.Root}}index.html">{{.Site}}</a></nav>
+{{- end}}
+
+{{- define "post" -}}
+{{template "head" .}}
+<article>
+<header>
+<h1>{{.Title}}</h1>
+{{- if .Meta}}
+<p class="meta">{{.Meta}}</p>
+{{- end}}
+</header>
+{{.Body}}
+<footer class="meta">
+{{- range .Tags}}
+<a href="{{$.Root}}tags/{{.Slug}}.html">#{{.Name}}</a>
+{{- end}}
+{{- range .Groups}}
+<a href="{{$.Root}}groups/{{.Slug}}.html">{{.Name}}</a>
+{{- end}}
+</footer>
+</article>
+</body>
+</html>
+{{end}}
+
+{{- define "list" -}}
+{{template "head" .}}
+<h1>{{.Title}}</h1>
+{{- if .Search}}
+<input id="search" type="search" placeholder="Search">
+<ul id="results"></ul>
+{{- end}}
+<ul class="posts">
+{{- range .Posts}}
+<li><a href="{{postPath $.Root .ID}}">{{.Title}}</a> <span class="meta">{{.UpdatedAt}}</span></li>
+{{- end}}
+</ul>
+{{- if .Tags}}
+<h2>Tags</h2>
+<ul class="tags">
+{{- range .Tags}}
+<li><a href="{{$.Root}}tags/{{.Slug}}.html">{{.Name}}</a> ({{.Count}})</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Groups}}
+<h2>Groups</h2>
+<ul class="groups">
+{{- range .Groups}}
+<li><a href="{{$.Root}}groups/{{.Slug}}.html">{{.Name}}</a> ({{.Count}})</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Search}}
+<script src="search-index.js"></script>
+<script src="search.js"></script>
+{{- end}}
+</body>
+</html>
+{{end}}
+`))
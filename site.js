@@ -0,0 +1,27 @@
+(function () {
+  var input = document.getElementById("search");
+  var results = document.getElementById("results");
+  if (!input || typeof SEARCH_INDEX === "undefined") {
+    return;
+  }
+  input.addEventListener("input", function () {
+    var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    results.innerHTML = "";
+    if (terms.length === 0) {
+      return;
+    }
+    SEARCH_INDEX.filter(function (e) {
+      var s = (e.title + " " + e.tags.join(" ") + " " + e.text).toLowerCase();
+      return terms.every(function (t) {
+        return s.indexOf(t) >= 0;
+      });
+    }).slice(0, 50).forEach(function (e) {
+      var li = document.createElement("li");
+      var a = document.createElement("a");
+      a.href = "posts/" + e.id + ".html";
+      a.textContent = e.title;
+      li.appendChild(a);
+      results.appendChild(li);
+    });
+  });
+})();
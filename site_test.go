@@ -0,0 +1,223 @@
+package docbasecli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func readSiteFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestWriteSite(t *testing.T) {
+	out := t.TempDir()
+	group := map[string]interface{}{"id": float64(1), "name": "Backend"}
+	posts := []docbase.Post{
+		{ID: 1, Title: "Intro", UpdatedAt: "2026-10-01", Tags: []docbase.Tag{{Name: "handbook"}},
+			Body: "See [setup](https://example.docbase.io/posts/2#install) and https://example.docbase.io/posts/99"},
+		{ID: 2, Title: "Setup", UpdatedAt: "2026-10-02", Tags: []docbase.Tag{{Name: "handbook"}, {Name: "dev/env"}},
+			Groups: []interface{}{group}, Body: "## install\nrun make"},
+	}
+	req := SiteBuildRequest{Domain: "example", Query: "tag:handbook", Out: out}
+	got, err := WriteSite(context.Background(), req, posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SiteBuildResult{Out: out, Posts: 2, Updated: []docbase.PostID{2, 1}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("result mismatch (-want, +got):%s\n", diff)
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"posts/1.html", []string{
+			`href="2.html#install"`,
+			`href="https://example.docbase.io/posts/99"`,
+			`href="../tags/handbook.html"`,
+			`<link rel="stylesheet" href="../style.css">`,
+		}},
+		{"posts/2.html", []string{`href="../tags/dev-env.html">#dev/env</a>`, `href="../groups/Backend.html">Backend</a>`}},
+		{"index.html", []string{
+			"<title>tag:handbook</title>",
+			`<li><a href="posts/2.html">Setup</a>`,
+			`<li><a href="tags/handbook.html">handbook</a> (2)</li>`,
+			`<li><a href="groups/Backend.html">Backend</a> (1)</li>`,
+			`<script src="search-index.js"></script>`,
+		}},
+		{"tags/handbook.html", []string{`<a href="../posts/1.html">Intro</a>`, `<a href="../posts/2.html">Setup</a>`}},
+		{"groups/Backend.html", []string{`<a href="../posts/2.html">Setup</a>`}},
+		{"search-index.js", []string{`var SEARCH_INDEX = [{"id":2,"title":"Setup","tags":["handbook","dev/env"],"text":"install run make"}`}},
+		{"search.js", []string{"SEARCH_INDEX"}},
+		{"style.css", []string{"li.task-list-item"}},
+	}
+	for _, tt := range tests {
+		got := readSiteFile(t, out, tt.file)
+		for _, w := range tt.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("want %q in %s:\n%s", w, tt.file, got)
+			}
+		}
+	}
+}
+
+func TestWriteSite_Incremental(t *testing.T) {
+	out := t.TempDir()
+	req := SiteBuildRequest{Domain: "example", Query: "tag:handbook", Out: out}
+	posts := []docbase.Post{
+		{ID: 1, Title: "Intro", UpdatedAt: "2026-10-01", Body: "https://example.docbase.io/posts/3"},
+		{ID: 2, Title: "Setup", UpdatedAt: "2026-10-02", Tags: []docbase.Tag{{Name: "old"}}},
+	}
+	if _, err := WriteSite(context.Background(), req, posts); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		req   SiteBuildRequest
+		posts []docbase.Post
+		want  *SiteBuildResult
+	}{
+		{
+			name:  "unchanged",
+			req:   req,
+			posts: posts,
+			want:  &SiteBuildResult{Out: out, Posts: 2},
+		},
+		{
+			name: "updated and added post linked from another",
+			req:  req,
+			posts: []docbase.Post{
+				posts[0],
+				{ID: 2, Title: "Setup", UpdatedAt: "2026-10-03"},
+				{ID: 3, Title: "New", UpdatedAt: "2026-10-01"},
+			},
+			want: &SiteBuildResult{Out: out, Posts: 3, Updated: []docbase.PostID{2, 1, 3}},
+		},
+		{
+			name:  "removed",
+			req:   req,
+			posts: []docbase.Post{posts[0]},
+			want:  &SiteBuildResult{Out: out, Posts: 1, Updated: []docbase.PostID{1}, Removed: []docbase.PostID{2, 3}},
+		},
+		{
+			name:  "full",
+			req:   SiteBuildRequest{Domain: "example", Query: "tag:handbook", Out: out, Full: true},
+			posts: []docbase.Post{posts[0]},
+			want:  &SiteBuildResult{Out: out, Posts: 1, Updated: []docbase.PostID{1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WriteSite(context.Background(), tt.req, tt.posts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("result mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(out, "posts", "2.html")); !os.IsNotExist(err) {
+		t.Errorf("page of removed post should be deleted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "tags", "old.html")); !os.IsNotExist(err) {
+		t.Errorf("page of unused tag should be deleted: %v", err)
+	}
+	if got := readSiteFile(t, out, "posts/1.html"); !strings.Contains(got, `href="https://example.docbase.io/posts/3"`) {
+		t.Errorf("link to removed post should not be rewritten:\n%s", got)
+	}
+}
+
+func TestWriteSite_Rebuild(t *testing.T) {
+	out := t.TempDir()
+	posts := []docbase.Post{
+		{ID: 1, Title: "Intro", UpdatedAt: "2026-10-01"},
+		{ID: 2, Title: "Setup", UpdatedAt: "2026-10-02"},
+	}
+	req := SiteBuildRequest{Domain: "example", Query: "tag:handbook", Out: out}
+	if _, err := WriteSite(context.Background(), req, posts); err != nil {
+		t.Fatal(err)
+	}
+
+	req.Query = "tag:dev"
+	tests := []struct {
+		name  string
+		req   func(r SiteBuildRequest) SiteBuildRequest
+		posts []docbase.Post
+		want  *SiteBuildResult
+	}{
+		{
+			name:  "query changed",
+			req:   func(r SiteBuildRequest) SiteBuildRequest { return r },
+			posts: posts[:1],
+			want:  &SiteBuildResult{Out: out, Posts: 1, Updated: []docbase.PostID{1}, Removed: []docbase.PostID{2}},
+		},
+		{
+			name:  "full",
+			req:   func(r SiteBuildRequest) SiteBuildRequest { r.Full = true; return r },
+			posts: posts[1:],
+			want:  &SiteBuildResult{Out: out, Posts: 1, Updated: []docbase.PostID{2}, Removed: []docbase.PostID{1}},
+		},
+		{
+			name:  "unchanged",
+			req:   func(r SiteBuildRequest) SiteBuildRequest { return r },
+			posts: posts[1:],
+			want:  &SiteBuildResult{Out: out, Posts: 1},
+		},
+		{
+			name:  "title changed",
+			req:   func(r SiteBuildRequest) SiteBuildRequest { r.Title = "Handbook"; return r },
+			posts: posts[1:],
+			want:  &SiteBuildResult{Out: out, Posts: 1, Updated: []docbase.PostID{2}},
+		},
+		{
+			name: "css changed",
+			req: func(r SiteBuildRequest) SiteBuildRequest {
+				r.Title, r.CSS = "Handbook", "body { color: red; }"
+				return r
+			},
+			posts: posts[1:],
+			want:  &SiteBuildResult{Out: out, Posts: 1, Updated: []docbase.PostID{2}},
+		},
+		{
+			name: "embed changed",
+			req: func(r SiteBuildRequest) SiteBuildRequest {
+				r.Title, r.CSS, r.Embed = "Handbook", "body { color: red; }", true
+				return r
+			},
+			posts: posts[1:],
+			want:  &SiteBuildResult{Out: out, Posts: 1, Updated: []docbase.PostID{2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WriteSite(context.Background(), tt.req(req), tt.posts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("result mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+	for _, name := range []string{"1.html", "2.html"} {
+		_, err := os.Stat(filepath.Join(out, "posts", name))
+		if exists := err == nil; exists != (name == "2.html") {
+			t.Errorf("posts/%s: unexpected existence %v", name, exists)
+		}
+	}
+}
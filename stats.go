@@ -0,0 +1,259 @@
+package docbasecli
+
+// チームの活動の集計
+//
+// 期間内に作成・更新されたメモを検索し、ユーザー・タグ・グループごとの件数や
+// コメント・スターの多いメモ、本文の長さの中央値を集計する。
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/micheam/go-docbase"
+)
+
+// DefaultStatsTop コメント・スターの多いメモとして表示する件数
+const DefaultStatsTop = 5
+
+// ActivityCount は、ユーザー・タグ・グループごとの作成・更新されたメモの件数。
+// Updated は、期間より前に作成され、期間内に更新されたメモの件数。
+type ActivityCount struct {
+	Name    string `json:"name"`
+	Created int    `json:"created"`
+	Updated int    `json:"updated"`
+}
+
+// RankedPost は、コメントやスターの数で順位付けしたメモ
+type RankedPost struct {
+	ID    docbase.PostID `json:"id"`
+	Title string         `json:"title"`
+	URL   string         `json:"url"`
+	Count int            `json:"count"`
+}
+
+// ActivityStats は、期間 [Since, Until) の活動の集計
+type ActivityStats struct {
+	Since        time.Time       `json:"since"`
+	Until        time.Time       `json:"until"`
+	Posts        int             `json:"posts"`
+	Created      int             `json:"created"`
+	Updated      int             `json:"updated"`
+	MedianLength int             `json:"median_length"` // 本文の文字数の中央値
+	ByUser       []ActivityCount `json:"by_user"`
+	ByTag        []ActivityCount `json:"by_tag"`
+	ByGroup      []ActivityCount `json:"by_group"`
+	TopCommented []RankedPost    `json:"top_commented"`
+	TopStarred   []RankedPost    `json:"top_starred"`
+}
+
+type ActivityStatsHandler func(ctx context.Context, stats ActivityStats) error
+
+type ActivityStatsRequest struct {
+	Domain string
+	Query  string
+	Since  time.Time
+	Until  time.Time
+	// Top コメント・スターの多いメモとして集計する件数。0 の場合は DefaultStatsTop
+	Top int
+}
+
+// statsQuery は、期間内に更新されたメモを検索するクエリを返す。
+// 検索の日付の範囲は終端の日を含むため、Until の直前の日までとする。
+func statsQuery(req ActivityStatsRequest) string {
+	q := fmt.Sprintf("changed_at:%s~%s", req.Since.Format("2006-01-02"), req.Until.Add(-time.Nanosecond).Format("2006-01-02"))
+	if req.Query != "" {
+		q = req.Query + " " + q
+	}
+	return q
+}
+
+// Stats は、期間内に作成・更新されたメモを集計して handle に渡す
+func Stats(ctx context.Context, req ActivityStatsRequest, handle ActivityStatsHandler) error {
+	query := statsQuery(req)
+	log.Printf("collect stats with query: %q", query)
+	posts, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &query})
+	if err != nil {
+		return err
+	}
+	return handle(ctx, BuildActivityStats(posts, req.Since, req.Until, req.Top))
+}
+
+// BuildActivityStats は、posts のうち期間 [since, until) に作成・更新されたものを集計する
+func BuildActivityStats(posts []docbase.Post, since, until time.Time, top int) ActivityStats {
+	if top <= 0 {
+		top = DefaultStatsTop
+	}
+	stats := ActivityStats{Since: since, Until: until}
+	inWindow := func(t time.Time) bool { return !t.Before(since) && t.Before(until) }
+	byUser := map[string]*ActivityCount{}
+	byTag := map[string]*ActivityCount{}
+	byGroup := map[string]*ActivityCount{}
+	count := func(m map[string]*ActivityCount, name string, created bool) {
+		c, ok := m[name]
+		if !ok {
+			c = &ActivityCount{Name: name}
+			m[name] = c
+		}
+		if created {
+			c.Created++
+		} else {
+			c.Updated++
+		}
+	}
+
+	var (
+		lengths   []int
+		commented []RankedPost
+		starred   []RankedPost
+	)
+	for _, post := range posts {
+		created := inWindow(postTime(post.CreatedAt))
+		if !created && !inWindow(postTime(post.UpdatedAt)) {
+			continue
+		}
+		stats.Posts++
+		if created {
+			stats.Created++
+		} else {
+			stats.Updated++
+		}
+		count(byUser, post.User.Name, created)
+		for _, tag := range post.Tags {
+			count(byTag, tag.Name, created)
+		}
+		for _, name := range PostGroupNames(post) {
+			count(byGroup, name, created)
+		}
+		lengths = append(lengths, utf8.RuneCountInString(post.Body))
+		ranked := RankedPost{ID: post.ID, Title: post.Title, URL: post.URL}
+		if n := len(post.Comments); n > 0 {
+			ranked.Count = n
+			commented = append(commented, ranked)
+		}
+		if post.Stars > 0 {
+			ranked.Count = post.Stars
+			starred = append(starred, ranked)
+		}
+	}
+	stats.MedianLength = median(lengths)
+	stats.ByUser = sortedCounts(byUser)
+	stats.ByTag = sortedCounts(byTag)
+	stats.ByGroup = sortedCounts(byGroup)
+	stats.TopCommented = topRanked(commented, top)
+	stats.TopStarred = topRanked(starred, top)
+	return stats
+}
+
+func median(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// sortedCounts は、作成・更新の合計の多い順 (同数の場合は名前順) に並べる
+func sortedCounts(m map[string]*ActivityCount) []ActivityCount {
+	counts := make([]ActivityCount, 0, len(m))
+	for _, c := range m {
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if a.Created+a.Updated != b.Created+b.Updated {
+			return a.Created+a.Updated > b.Created+b.Updated
+		}
+		return a.Name < b.Name
+	})
+	return counts
+}
+
+// topRanked は、数の多い順 (同数の場合は ID 順) に上位 n 件を返す
+func topRanked(posts []RankedPost, n int) []RankedPost {
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].Count != posts[j].Count {
+			return posts[i].Count > posts[j].Count
+		}
+		return posts[i].ID < posts[j].ID
+	})
+	if len(posts) > n {
+		posts = posts[:n]
+	}
+	return append([]RankedPost{}, posts...)
+}
+
+// OutputActivityStats は、集計を format で指定された形式で出力する。
+// csv の場合は、section, key, name, value の縦持ちの形式で出力する。
+func OutputActivityStats(out io.Writer, format Format) ActivityStatsHandler {
+	return func(ctx context.Context, s ActivityStats) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, s)
+		case FormatCSV:
+			return writeCSV(out, []string{"section", "key", "name", "value"}, activityRecords(s))
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Period:\t%s - %s\n", s.Since.Format("2006-01-02"), s.Until.Add(-time.Nanosecond).Format("2006-01-02"))
+		fmt.Fprintf(w, "Posts:\t%d (created %d, updated %d)\n", s.Posts, s.Created, s.Updated)
+		fmt.Fprintf(w, "MedianLength:\t%d\n", s.MedianLength)
+		for _, section := range []struct {
+			label  string
+			counts []ActivityCount
+		}{{"USER", s.ByUser}, {"TAG", s.ByTag}, {"GROUP", s.ByGroup}} {
+			fmt.Fprintf(w, "\n%s\tCREATED\tUPDATED\n", section.label)
+			for _, c := range section.counts {
+				fmt.Fprintf(w, "%s\t%d\t%d\n", c.Name, c.Created, c.Updated)
+			}
+		}
+		for _, section := range []struct {
+			label string
+			posts []RankedPost
+		}{{"COMMENTS", s.TopCommented}, {"STARS", s.TopStarred}} {
+			fmt.Fprintf(w, "\n%s\tID\tTITLE\n", section.label)
+			for _, p := range section.posts {
+				fmt.Fprintf(w, "%d\t%d\t%s\n", p.Count, p.ID, p.Title)
+			}
+		}
+		return w.Flush()
+	}
+}
+
+func activityRecords(s ActivityStats) [][]string {
+	records := [][]string{
+		{"summary", "", "posts", strconv.Itoa(s.Posts)},
+		{"summary", "", "created", strconv.Itoa(s.Created)},
+		{"summary", "", "updated", strconv.Itoa(s.Updated)},
+		{"summary", "", "median_length", strconv.Itoa(s.MedianLength)},
+	}
+	for _, section := range []struct {
+		name   string
+		counts []ActivityCount
+	}{{"user", s.ByUser}, {"tag", s.ByTag}, {"group", s.ByGroup}} {
+		for _, c := range section.counts {
+			records = append(records,
+				[]string{section.name, c.Name, "created", strconv.Itoa(c.Created)},
+				[]string{section.name, c.Name, "updated", strconv.Itoa(c.Updated)})
+		}
+	}
+	for _, section := range []struct {
+		name  string
+		posts []RankedPost
+	}{{"top_commented", s.TopCommented}, {"top_starred", s.TopStarred}} {
+		for _, p := range section.posts {
+			records = append(records, []string{section.name, p.ID.String(), p.Title, strconv.Itoa(p.Count)})
+		}
+	}
+	return records
+}
@@ -0,0 +1,149 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+var statsPosts = []docbase.Post{
+	{ID: 1, Title: "Created", User: docbase.User{Name: "alice"}, Body: "12345",
+		CreatedAt: "2021-04-02T10:00:00+09:00", UpdatedAt: "2021-04-02T10:00:00+09:00",
+		Tags: []docbase.Tag{{Name: "daily"}}, Groups: []interface{}{map[string]interface{}{"name": "Dev"}},
+		Comments: []interface{}{"c"}, Stars: 3},
+	{ID: 2, Title: "Updated", User: docbase.User{Name: "bob"}, Body: "1234567",
+		CreatedAt: "2021-01-01T00:00:00+09:00", UpdatedAt: "2021-04-20T00:00:00+09:00",
+		Tags: []docbase.Tag{{Name: "daily"}, {Name: "ops"}}, Comments: []interface{}{"c", "c"}, Stars: 3},
+	{ID: 3, Title: "Another", User: docbase.User{Name: "alice"}, Body: "123",
+		CreatedAt: "2021-04-30T23:59:59+09:00", UpdatedAt: "2021-04-30T23:59:59+09:00"},
+	{ID: 4, Title: "Outside", User: docbase.User{Name: "carol"}, Body: "1",
+		CreatedAt: "2021-03-01T00:00:00+09:00", UpdatedAt: "2021-05-01T00:00:00+09:00", Stars: 10},
+}
+
+func TestBuildActivityStats(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	since, until := time.Date(2021, 4, 1, 0, 0, 0, 0, jst), time.Date(2021, 5, 1, 0, 0, 0, 0, jst)
+	tests := []struct {
+		name string
+		top  int
+		want ActivityStats
+	}{
+		{
+			name: "default top",
+			want: ActivityStats{
+				Since: since, Until: until,
+				Posts: 3, Created: 2, Updated: 1, MedianLength: 5,
+				ByUser:  []ActivityCount{{Name: "alice", Created: 2}, {Name: "bob", Updated: 1}},
+				ByTag:   []ActivityCount{{Name: "daily", Created: 1, Updated: 1}, {Name: "ops", Updated: 1}},
+				ByGroup: []ActivityCount{{Name: "Dev", Created: 1}},
+				TopCommented: []RankedPost{
+					{ID: 2, Title: "Updated", Count: 2},
+					{ID: 1, Title: "Created", Count: 1},
+				},
+				TopStarred: []RankedPost{
+					{ID: 1, Title: "Created", Count: 3},
+					{ID: 2, Title: "Updated", Count: 3},
+				},
+			},
+		},
+		{
+			name: "top 1",
+			top:  1,
+			want: ActivityStats{
+				Since: since, Until: until,
+				Posts: 3, Created: 2, Updated: 1, MedianLength: 5,
+				ByUser:       []ActivityCount{{Name: "alice", Created: 2}, {Name: "bob", Updated: 1}},
+				ByTag:        []ActivityCount{{Name: "daily", Created: 1, Updated: 1}, {Name: "ops", Updated: 1}},
+				ByGroup:      []ActivityCount{{Name: "Dev", Created: 1}},
+				TopCommented: []RankedPost{{ID: 2, Title: "Updated", Count: 2}},
+				TopStarred:   []RankedPost{{ID: 1, Title: "Created", Count: 3}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildActivityStats(statsPosts, since, until, tt.top)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("BuildActivityStats mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestStatsQuery(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		req  ActivityStatsRequest
+		want string
+	}{
+		{
+			ActivityStatsRequest{Since: time.Date(2021, 4, 1, 0, 0, 0, 0, jst), Until: time.Date(2021, 5, 1, 0, 0, 0, 0, jst)},
+			"changed_at:2021-04-01~2021-04-30",
+		},
+		{
+			ActivityStatsRequest{Query: "tag:daily", Since: time.Date(2021, 4, 1, 0, 0, 0, 0, jst), Until: time.Date(2021, 4, 16, 12, 0, 0, 0, jst)},
+			"tag:daily changed_at:2021-04-01~2021-04-16",
+		},
+	}
+	for _, tt := range tests {
+		if got := statsQuery(tt.req); got != tt.want {
+			t.Errorf("want %q, but got %q", tt.want, got)
+		}
+	}
+}
+
+func TestOutputActivityStats(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	s := BuildActivityStats(statsPosts[:1], time.Date(2021, 4, 1, 0, 0, 0, 0, jst), time.Date(2021, 5, 1, 0, 0, 0, 0, jst), 0)
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatText, `Period:        2021-04-01 - 2021-04-30
+Posts:         1 (created 1, updated 0)
+MedianLength:  5
+
+USER   CREATED  UPDATED
+alice  1        0
+
+TAG    CREATED  UPDATED
+daily  1        0
+
+GROUP  CREATED  UPDATED
+Dev    1        0
+
+COMMENTS  ID  TITLE
+1         1   Created
+
+STARS  ID  TITLE
+3      1   Created
+`},
+		{FormatCSV, `section,key,name,value
+summary,,posts,1
+summary,,created,1
+summary,,updated,0
+summary,,median_length,5
+user,alice,created,1
+user,alice,updated,0
+tag,daily,created,1
+tag,daily,updated,0
+group,Dev,created,1
+group,Dev,updated,0
+top_commented,1,Created,1
+top_starred,1,Created,3
+`},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		if err := OutputActivityStats(buf, tt.format)(context.Background(), s); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+			t.Errorf("%s output mismatch (-want, +got):%s\n", tt.format, diff)
+		}
+	}
+}
@@ -2,8 +2,15 @@ package docbasecli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/micheam/docbase-cli/pointer"
 	"github.com/micheam/go-docbase"
 )
 
@@ -20,3 +27,187 @@ func ListTags(ctx context.Context, req ListTagsRequest, presenter TagCollectionP
 	}
 	return presenter(ctx, tags)
 }
+
+// OutputTags は、タグの一覧を format で指定された形式で出力する
+func OutputTags(out io.Writer, format Format) TagCollectionPresenter {
+	return func(ctx context.Context, tags []docbase.Tag) error {
+		names := make([]string, len(tags))
+		for i := range tags {
+			names[i] = tags[i].Name
+		}
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, names)
+		case FormatCSV:
+			records := make([][]string, len(names))
+			for i := range names {
+				records[i] = []string{names[i]}
+			}
+			return writeCSV(out, []string{"name"}, records)
+		}
+		for _, name := range names {
+			if _, err := fmt.Fprintln(out, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+/***************************************
+ * Tag Stats
+ ***************************************/
+
+// TagStat は、タグが付与されたメモの件数
+type TagStat struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TagStatsPresenter func(ctx context.Context, stats []TagStat) error
+
+type ListTagStatsRequest struct {
+	Domain string
+
+	// MaxCount 0 以上の場合、件数がこの値以下のタグのみを対象とする
+	MaxCount int
+
+	Concurrency int
+}
+
+// ListTagStats は、全てのタグについて付与されたメモの件数を集計する。
+// 件数は検索API (tag:NAME) の meta.total から得る。
+func ListTagStats(ctx context.Context, req ListTagStatsRequest, presenter TagStatsPresenter) error {
+	tags, err := docbase.ListTags(ctx, req.Domain)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	concurrency := req.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+	var (
+		stats = make([]TagStat, len(tags))
+		errs  = make([]error, len(tags))
+		sem   = make(chan struct{}, concurrency)
+		wg    sync.WaitGroup
+	)
+	for i := range tags {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			stats[i].Name = tags[i].Name
+			stats[i].Count, errs[i] = countPosts(ctx, req.Domain, "tag:"+tags[i].Name)
+		}(i)
+	}
+	wg.Wait()
+	for i := range errs {
+		if errs[i] != nil {
+			return fmt.Errorf("failed to count posts of tag %q: %w", tags[i].Name, errs[i])
+		}
+	}
+	if req.MaxCount >= 0 {
+		filtered := stats[:0]
+		for _, s := range stats {
+			if s.Count <= req.MaxCount {
+				filtered = append(filtered, s)
+			}
+		}
+		stats = filtered
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Count > stats[j].Count })
+	return presenter(ctx, stats)
+}
+
+// countPosts は、query にヒットするメモの件数を返す
+func countPosts(ctx context.Context, domain, query string) (int, error) {
+	var total int
+	req := ListPostsRequest{Domain: domain, Query: &query, PerPage: pointer.IntPtr(1)}
+	err := RetryOnRateLimit(ctx, func() error {
+		return ListPosts(ctx, req, func(_ context.Context, _ []docbase.Post, m docbase.Meta) error {
+			total = m.Total
+			return nil
+		})
+	})
+	return total, err
+}
+
+// OutputTagStats は、タグの集計結果を format で指定された形式で出力する
+func OutputTagStats(out io.Writer, format Format) TagStatsPresenter {
+	return func(ctx context.Context, stats []TagStat) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, stats)
+		case FormatCSV:
+			records := make([][]string, len(stats))
+			for i, s := range stats {
+				records[i] = []string{s.Name, strconv.Itoa(s.Count)}
+			}
+			return writeCSV(out, []string{"name", "count"}, records)
+		}
+		for _, s := range stats {
+			if _, err := fmt.Fprintf(out, "%d\t%s\n", s.Count, s.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+/***************************************
+ * Rename/Merge Tags
+ ***************************************/
+
+type MergeTagsRequest struct {
+	Domain string
+	From   []string
+	Into   string
+}
+
+// PlanMergeTags は、From のいずれかのタグが付いた全てのメモについて、
+// それらのタグを Into に置き換える変更を計画する。
+// 結果は ApplyBulk で適用し、UndoBulk で取り消すことができる。
+func PlanMergeTags(ctx context.Context, req MergeTagsRequest) (*BulkJournal, error) {
+	if len(req.From) == 0 {
+		return nil, errors.New("no tags to merge")
+	}
+	var (
+		posts []docbase.Post
+		seen  = map[docbase.PostID]bool{}
+	)
+	for _, from := range req.From {
+		query := "tag:" + from
+		found, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &query})
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range found {
+			if !seen[post.ID] {
+				seen[post.ID] = true
+				posts = append(posts, post)
+			}
+		}
+	}
+	return planMergeTags(req, posts), nil
+}
+
+// planMergeTags は、posts の From のタグを Into に置き換える変更を計画する
+func planMergeTags(req MergeTagsRequest, posts []docbase.Post) *BulkJournal {
+	queries := make([]string, len(req.From))
+	for i, from := range req.From {
+		queries[i] = "tag:" + from
+	}
+	return planBulk(BulkRequest{
+		Domain:    req.Domain,
+		Query:     strings.Join(queries, " OR "),
+		Operation: fmt.Sprintf("merge tags %v into %s", req.From, req.Into),
+		Change: func(s PostState) PostState {
+			for _, from := range req.From {
+				s = RemoveTag(from)(s)
+			}
+			return AddTag(req.Into)(s)
+		},
+	}, posts)
+}
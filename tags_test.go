@@ -0,0 +1,139 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestOutputTags(t *testing.T) {
+	tags := []docbase.Tag{{Name: "golang"}, {Name: "docbase"}}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatText, "golang\ndocbase\n"},
+		{FormatJSON, "[\n  \"golang\",\n  \"docbase\"\n]\n"},
+		{FormatCSV, "name\ngolang\ndocbase\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := OutputTags(buf, tt.format)(context.Background(), tags); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestOutputTagStats(t *testing.T) {
+	stats := []TagStat{{Name: "golang", Count: 12}, {Name: "golnag", Count: 1}}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatText, "12\tgolang\n1\tgolnag\n"},
+		{FormatJSON, `[
+  {
+    "name": "golang",
+    "count": 12
+  },
+  {
+    "name": "golnag",
+    "count": 1
+  }
+]
+`},
+		{FormatCSV, "name,count\ngolang,12\ngolnag,1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := OutputTagStats(buf, tt.format)(context.Background(), stats); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("output mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": FormatText, "text": FormatText, "json": FormatJSON, "csv": FormatCSV} {
+		got, err := ParseFormat(in)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("want error for unsupported format")
+	}
+}
+
+func TestPlanMergeTags(t *testing.T) {
+	tags := func(names ...string) []docbase.Tag {
+		ts := make([]docbase.Tag, len(names))
+		for i, n := range names {
+			ts[i] = docbase.Tag{Name: n}
+		}
+		return ts
+	}
+	tests := []struct {
+		name  string
+		from  []string
+		into  string
+		posts []docbase.Post
+		want  map[docbase.PostID][]string
+	}{
+		{
+			name:  "rename",
+			from:  []string{"golnag"},
+			into:  "golang",
+			posts: []docbase.Post{{ID: 1, Tags: tags("golnag", "memo")}},
+			want:  map[docbase.PostID][]string{1: {"memo", "golang"}},
+		},
+		{
+			name:  "target already present",
+			from:  []string{"golnag"},
+			into:  "golang",
+			posts: []docbase.Post{{ID: 1, Tags: tags("golang", "golnag")}},
+			want:  map[docbase.PostID][]string{1: {"golang"}},
+		},
+		{
+			name:  "source equals target",
+			from:  []string{"golang"},
+			into:  "golang",
+			posts: []docbase.Post{{ID: 1, Tags: tags("memo", "golang")}},
+			want:  map[docbase.PostID][]string{},
+		},
+		{
+			name: "several sources on one post",
+			from: []string{"go", "golnag"},
+			into: "golang",
+			posts: []docbase.Post{
+				{ID: 1, Tags: tags("go", "memo", "golnag")},
+				{ID: 2, Tags: tags("go")},
+			},
+			want: map[docbase.PostID][]string{1: {"memo", "golang"}, 2: {"golang"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := planMergeTags(MergeTagsRequest{Domain: "example", From: tt.from, Into: tt.into}, tt.posts)
+			got := map[docbase.PostID][]string{}
+			for _, e := range j.Entries {
+				got[e.ID] = e.After.Tags
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("planned tags mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package docbasecli
+
+// メモのテンプレート
+//
+// テンプレートは YAML のフロントマターを持つ Markdown ファイルで、
+// TemplateDir に <NAME>.md として配置する。
+// (DocBase API はテンプレートの取得を提供していないため、ローカルのファイルのみを扱う)
+//
+//	---
+//	title: "{{.Date}} 日報"
+//	tags: [daily, "{{.User}}"]
+//	scope: group
+//	groups: [Backend]
+//	---
+//	## やったこと
+//
+// タイトル・本文・タグは text/template として展開される。
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/micheam/docbase-cli/text"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultTitleTemplate 設定ファイルで DefaultTitle が指定されていない場合のタイトル
+const DefaultTitleTemplate = "{{.Date}} 作業メモ"
+
+// PostTemplate は、メモのテンプレート
+type PostTemplate struct {
+	Name string `yaml:"-"`
+	Path string `yaml:"-"`
+
+	Title  string   `yaml:"title"`
+	Tags   []string `yaml:"tags"`
+	Scope  string   `yaml:"scope"`
+	Groups []string `yaml:"groups"`
+	Draft  *bool    `yaml:"draft"`
+	Body   string   `yaml:"-"`
+}
+
+// TemplateData は、テンプレートの展開時に参照できる値
+type TemplateData struct {
+	Now  time.Time
+	Date string // 2006-01-02
+	Time string // 15:04
+	Week string // 2006-W01 (ISO 8601)
+	User string // 設定ファイルの UserID
+}
+
+func NewTemplateData(now time.Time, user string) TemplateData {
+	year, week := now.ISOWeek()
+	return TemplateData{
+		Now:  now,
+		Date: now.Format("2006-01-02"),
+		Time: now.Format("15:04"),
+		Week: fmt.Sprintf("%04d-W%02d", year, week),
+		User: user,
+	}
+}
+
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"addDays": func(days int, t time.Time) time.Time {
+		return t.AddDate(0, 0, days)
+	},
+}
+
+// RenderTemplateString は、s を text/template として data で展開する
+func RenderTemplateString(name, s string, data TemplateData) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %q: %w", name, err)
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %q: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// Render は、テンプレートのタイトル・本文・タグを data で展開したものを返す
+func (t PostTemplate) Render(data TemplateData) (*PostTemplate, error) {
+	var (
+		rendered = t
+		err      error
+	)
+	if rendered.Title, err = RenderTemplateString(t.Name+":title", t.Title, data); err != nil {
+		return nil, err
+	}
+	if rendered.Body, err = RenderTemplateString(t.Name+":body", t.Body, data); err != nil {
+		return nil, err
+	}
+	rendered.Tags = make([]string, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		s, err := RenderTemplateString(t.Name+":tags", tag, data)
+		if err != nil {
+			return nil, err
+		}
+		if s = strings.TrimSpace(s); s != "" {
+			rendered.Tags = append(rendered.Tags, s)
+		}
+	}
+	return &rendered, nil
+}
+
+// ParseTemplate は、フロントマター付きの Markdown をテンプレートとして読み込む
+func ParseTemplate(name string, r io.Reader) (*PostTemplate, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	t := &PostTemplate{Name: name}
+	front, body := splitFrontMatter(text.Dos2Unix(string(b)))
+	if front != "" {
+		if err := yaml.Unmarshal([]byte(front), t); err != nil {
+			return nil, fmt.Errorf("failed to parse front matter of %q: %w", name, err)
+		}
+	}
+	t.Body = body
+	return t, nil
+}
+
+// splitFrontMatter は、 --- で囲まれた先頭のブロックと残りの本文に分割する
+func splitFrontMatter(s string) (front, body string) {
+	if !strings.HasPrefix(s, "---\n") {
+		return "", s
+	}
+	sc := bufio.NewScanner(strings.NewReader(s[4:]))
+	var n int
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "---" {
+			front = s[4 : 4+n]
+			body = strings.TrimPrefix(s[4+n+len(line):], "\n")
+			return front, body
+		}
+		n += len(line) + 1
+	}
+	return "", s
+}
+
+// TemplateDir は、テンプレートを配置するディレクトリを返す
+func TemplateDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// TemplatePath は、名前 name のテンプレートのパスを返す
+func TemplatePath(name string) (string, error) {
+	dir, err := TemplateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".md"), nil
+}
+
+// LoadTemplate は、名前 name のテンプレートを読み込む
+func LoadTemplate(name string) (*PostTemplate, error) {
+	path, err := TemplatePath(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("template %q not found: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+	t, err := ParseTemplate(name, f)
+	if err != nil {
+		return nil, err
+	}
+	t.Path = path
+	return t, nil
+}
+
+// ListTemplates は、 TemplateDir に配置された全てのテンプレートを名前順に返す
+func ListTemplates() ([]PostTemplate, error) {
+	dir, err := TemplateDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	templates := make([]PostTemplate, 0, len(paths))
+	for _, path := range paths {
+		t, err := LoadTemplate(strings.TrimSuffix(filepath.Base(path), ".md"))
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+	return templates, nil
+}
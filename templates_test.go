@@ -0,0 +1,86 @@
+package docbasecli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/docbase-cli/pointer"
+)
+
+func TestParseTemplate(t *testing.T) {
+	src := "---\r\n" +
+		"title: \"{{.Date}} 日報\"\r\n" +
+		"tags: [daily, \"{{.User}}\"]\r\n" +
+		"scope: group\r\n" +
+		"groups: [Backend]\r\n" +
+		"draft: false\r\n" +
+		"---\r\n" +
+		"## {{.Week}}\r\n" +
+		"by {{env \"DOCBASE_TEST_TEAM\"}}\r\n"
+	tmpl, err := ParseTemplate("daily", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCBASE_TEST_TEAM", "team-a")
+	now := time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local)
+	got, err := tmpl.Render(NewTemplateData(now, "micheam"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PostTemplate{
+		Name:   "daily",
+		Title:  "2026-10-19 日報",
+		Tags:   []string{"daily", "micheam"},
+		Scope:  "group",
+		Groups: []string{"Backend"},
+		Draft:  pointer.BoolPtr(false),
+		Body:   "## 2026-W43\nby team-a\n",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("rendered template mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestParseTemplate_withoutFrontMatter(t *testing.T) {
+	tmpl, err := ParseTemplate("plain", strings.NewReader("# {{.Date}}\n---\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Title != "" || tmpl.Body != "# {{.Date}}\n---\n" {
+		t.Errorf("unexpected template: %+v", tmpl)
+	}
+}
+
+func TestListTemplates(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCBASE_CONFIG_DIR", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"weekly.md": "---\ntitle: \"{{.Week}} 週報\"\n---\n",
+		"daily.md":  "---\ntitle: \"{{.Date}} 日報\"\n---\n",
+		"memo.txt":  "ignored",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "templates", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts, err := ListTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tmpl := range ts {
+		names = append(names, tmpl.Name+":"+tmpl.Title)
+	}
+	want := []string{"daily:{{.Date}} 日報", "weekly:{{.Week}} 週報"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("templates mismatch (-want, +got):%s\n", diff)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build linux
+// +build linux
+
+package docbasecli
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlReadTermios  = unix.TCGETS
+	ioctlWriteTermios = unix.TCSETS
+)
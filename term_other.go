@@ -0,0 +1,23 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package docbasecli
+
+import (
+	"errors"
+	"os"
+)
+
+var errTerminalUnsupported = errors.New("interactive picker is not supported on this platform")
+
+func openTTY() (*os.File, error) {
+	return nil, errTerminalUnsupported
+}
+
+func makeRaw(fd int) (func() error, error) {
+	return nil, errTerminalUnsupported
+}
+
+func terminalSize(fd int) (width, height int, err error) {
+	return 0, 0, errTerminalUnsupported
+}
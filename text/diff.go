@@ -0,0 +1,268 @@
+package text
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOp は、行単位の差分の種類
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffDelete
+	DiffInsert
+)
+
+// DiffLine は、差分の１行
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// maxEditDistance これを超える編集距離の差分は計算せず、全行の置換とみなす
+const maxEditDistance = 1000
+
+// DiffLines は、a を b に変換する行単位の差分を Myers のアルゴリズムで求める。
+// 中央の snake で分割する線形空間の方式のため、メモリは行数に比例する量しか使わない。
+func DiffLines(a, b []string) []DiffLine {
+	lines := make([]DiffLine, 0, len(a)+len(b))
+	pre, suf := commonAffix(a, b)
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(ma) > 0 && len(mb) > 0 {
+		if _, _, _, _, ok := middleSnake(ma, mb, (maxEditDistance+1)/2); !ok {
+			lines = appendEqual(lines, a[:pre])
+			lines = appendReplace(lines, ma, mb)
+			return appendEqual(lines, a[len(a)-suf:])
+		}
+	}
+	return appendDiff(lines, a, b)
+}
+
+// commonAffix は、a と b に共通する先頭と末尾の行数を返す
+func commonAffix(a, b []string) (pre, suf int) {
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	return pre, suf
+}
+
+// appendDiff は、a を b に変換する差分を lines に追加する
+func appendDiff(lines []DiffLine, a, b []string) []DiffLine {
+	pre, suf := commonAffix(a, b)
+	lines = appendEqual(lines, a[:pre])
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(ma) == 0 || len(mb) == 0 {
+		lines = appendReplace(lines, ma, mb)
+	} else {
+		// 先頭と末尾が異なるため編集距離は 2 以上で、分割した両側はそれより小さくなる
+		x, y, u, v, _ := middleSnake(ma, mb, len(ma)+len(mb))
+		lines = appendDiff(lines, ma[:x], mb[:y])
+		lines = appendEqual(lines, ma[x:u])
+		lines = appendDiff(lines, ma[u:], mb[v:])
+	}
+	return appendEqual(lines, a[len(a)-suf:])
+}
+
+// appendEqual は、a を共通の行として lines に追加する
+func appendEqual(lines []DiffLine, a []string) []DiffLine {
+	for _, s := range a {
+		lines = append(lines, DiffLine{DiffEqual, s})
+	}
+	return lines
+}
+
+// appendReplace は、a の全行の削除と b の全行の追加を lines に追加する
+func appendReplace(lines []DiffLine, a, b []string) []DiffLine {
+	for _, s := range a {
+		lines = append(lines, DiffLine{DiffDelete, s})
+	}
+	for _, s := range b {
+		lines = append(lines, DiffLine{DiffInsert, s})
+	}
+	return lines
+}
+
+// middleSnake は、a を b に変換する最短の編集経路の中央にある snake (x, y)-(u, v) を返す。
+// 前方と後方から同時に探索し、limit 回の探索で出会わない場合は ok が false になる。
+func middleSnake(a, b []string, limit int) (x, y, u, v int, ok bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	if max > limit {
+		max = limit
+	}
+	// vf[k+off] は前方から対角線 k 上で到達した x、
+	// vb[k+off] は後方 (a, b を逆順にしたもの) から対角線 k 上で到達した x
+	off := max + 1
+	vf := make([]int, 2*max+3)
+	vb := make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x0 int
+			if k == -d || (k != d && vf[k-1+off] < vf[k+1+off]) {
+				x0 = vf[k+1+off]
+			} else {
+				x0 = vf[k-1+off] + 1
+			}
+			y0 := x0 - k
+			x, y := x0, y0
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[k+off] = x
+			if c := delta - k; odd && -(d-1) <= c && c <= d-1 && x+vb[c+off] >= n {
+				return x0, y0, x, y, true
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x0 int
+			if k == -d || (k != d && vb[k-1+off] < vb[k+1+off]) {
+				x0 = vb[k+1+off]
+			} else {
+				x0 = vb[k-1+off] + 1
+			}
+			y0 := x0 - k
+			x, y := x0, y0
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			vb[k+off] = x
+			if c := delta - k; !odd && -d <= c && c <= d && x+vf[c+off] >= n {
+				return n - x, m - y, n - x0, m - y0, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+// UnifiedDiff は、from から to への差分を unified 形式で返す。
+// 差分がない場合は空文字を返す。
+func UnifiedDiff(from, to, fromName, toName string, context int) string {
+	lines := DiffLines(splitLines(from), splitLines(to))
+	changed := false
+	for _, l := range lines {
+		if l.Op != DiffEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	// 各行の元の行番号 (1 始まり)
+	aLine, bLine := make([]int, len(lines)), make([]int, len(lines))
+	for i, ai, bi := 0, 1, 1; i < len(lines); i++ {
+		aLine[i], bLine[i] = ai, bi
+		if lines[i].Op != DiffInsert {
+			ai++
+		}
+		if lines[i].Op != DiffDelete {
+			bi++
+		}
+	}
+	for i := 0; i < len(lines); {
+		if lines[i].Op == DiffEqual {
+			i++
+			continue
+		}
+		// hunk の範囲を決める
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != DiffEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].Op == DiffEqual {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				end += context
+				if end > len(lines) {
+					end = len(lines)
+				}
+				break
+			}
+			end = next
+		}
+		var aCount, bCount int
+		for _, l := range lines[start:end] {
+			if l.Op != DiffInsert {
+				aCount++
+			}
+			if l.Op != DiffDelete {
+				bCount++
+			}
+		}
+		aStart, bStart := aLine[start], bLine[start]
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+		fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, l := range lines[start:end] {
+			switch l.Op {
+			case DiffEqual:
+				sb.WriteString(" ")
+			case DiffDelete:
+				sb.WriteString("-")
+			case DiffInsert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(l.Text)
+			sb.WriteString("\n")
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// ColorizeDiff は、unified 形式の差分を ANSI エスケープシーケンスで色付けする
+func ColorizeDiff(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	sb := new(strings.Builder)
+	// ファイル名のヘッダは最初のハンクより前の行のみ (本文の "--- " などと区別する)
+	inHunk := false
+	for i, l := range lines {
+		body := strings.TrimSuffix(l, "\n")
+		nl := l[len(body):]
+		switch {
+		case !inHunk && i < 2 && (strings.HasPrefix(l, "---") || strings.HasPrefix(l, "+++")):
+			sb.WriteString(ansiBold + body + ansiReset + nl)
+		case strings.HasPrefix(l, "@@"):
+			inHunk = true
+			sb.WriteString(ansiCyan + body + ansiReset + nl)
+		case strings.HasPrefix(l, "-"):
+			sb.WriteString(ansiRed + body + ansiReset + nl)
+		case strings.HasPrefix(l, "+"):
+			sb.WriteString(ansiGreen + body + ansiReset + nl)
+		default:
+			sb.WriteString(l)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,168 @@
+package text
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// checkDiffLines は、lines が a を b に変換する差分であることを確かめ、編集の行数を返す
+func checkDiffLines(t *testing.T, a, b []string, lines []DiffLine) int {
+	t.Helper()
+	var (
+		gotA, gotB []string
+		edits      int
+	)
+	for _, l := range lines {
+		if l.Op != DiffInsert {
+			gotA = append(gotA, l.Text)
+		}
+		if l.Op != DiffDelete {
+			gotB = append(gotB, l.Text)
+		}
+		if l.Op != DiffEqual {
+			edits++
+		}
+	}
+	if diff := cmp.Diff(a, gotA, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("source lines mismatch (-want, +got):%s\n", diff)
+	}
+	if diff := cmp.Diff(b, gotB, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("destination lines mismatch (-want, +got):%s\n", diff)
+	}
+	return edits
+}
+
+func TestDiffLines(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+	// 最短編集距離は 5
+	if edits := checkDiffLines(t, a, b, DiffLines(a, b)); edits != 5 {
+		t.Errorf("want 5 edits, got %d", edits)
+	}
+}
+
+func TestDiffLines_shortest(t *testing.T) {
+	// 最長共通部分列から求めた最短編集距離と比べる
+	lcs := func(a, b []string) int {
+		dp := make([][]int, len(a)+1)
+		for i := range dp {
+			dp[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				switch {
+				case a[i] == b[j]:
+					dp[i][j] = dp[i+1][j+1] + 1
+				case dp[i+1][j] > dp[i][j+1]:
+					dp[i][j] = dp[i+1][j]
+				default:
+					dp[i][j] = dp[i][j+1]
+				}
+			}
+		}
+		return dp[0][0]
+	}
+	rnd := rand.New(rand.NewSource(1))
+	lines := func() []string {
+		ls := make([]string, rnd.Intn(12))
+		for i := range ls {
+			ls[i] = string(rune('a' + rnd.Intn(3)))
+		}
+		return ls
+	}
+	for i := 0; i < 500; i++ {
+		a, b := lines(), lines()
+		want := len(a) + len(b) - 2*lcs(a, b)
+		if got := checkDiffLines(t, a, b, DiffLines(a, b)); got != want {
+			t.Fatalf("DiffLines(%q, %q): want %d edits, got %d", a, b, want, got)
+		}
+	}
+}
+
+func TestDiffLines_large(t *testing.T) {
+	const n = 20000
+	a, b := make([]string, n), make([]string, n)
+	for i := range a {
+		a[i] = fmt.Sprintf("a%d", i)
+		b[i] = fmt.Sprintf("b%d", i)
+	}
+	t.Run("fully different", func(t *testing.T) {
+		if got := checkDiffLines(t, a, b, DiffLines(a, b)); got != 2*n {
+			t.Errorf("want %d edits, got %d", 2*n, got)
+		}
+	})
+	t.Run("few changes", func(t *testing.T) {
+		c := append([]string{}, a...)
+		c[100], c[n/2], c[n-100] = "x", "y", "z"
+		if got := checkDiffLines(t, a, c, DiffLines(a, c)); got != 6 {
+			t.Errorf("want 6 edits, got %d", got)
+		}
+	})
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	to := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n"
+	want := `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -8,3 +8,4 @@
+ 8
+ 9
+ 10
++11
+`
+	got := UnifiedDiff(from, to, "a", "b", 3)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unified diff mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestUnifiedDiff_noChanges(t *testing.T) {
+	if got := UnifiedDiff("a\r\nb\n", "a\nb", "a", "b", 3); got != "" {
+		t.Errorf("want empty diff, got %q", got)
+	}
+}
+
+func TestUnifiedDiff_fromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if diff := cmp.Diff(want, UnifiedDiff("", "x\ny\n", "a", "b", 3)); diff != "" {
+		t.Errorf("unified diff mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestColorizeDiff(t *testing.T) {
+	tests := []struct {
+		name, diff, want string
+	}{
+		{
+			name: "hunk only",
+			diff: "@@ -1 +1 @@\n-a\n+b\n c\n",
+			want: "\x1b[36m@@ -1 +1 @@\x1b[0m\n\x1b[31m-a\x1b[0m\n\x1b[32m+b\x1b[0m\n c\n",
+		},
+		{
+			name: "headers and removed/added horizontal rules",
+			diff: "--- a\n+++ b\n@@ -1 +1 @@\n----\n+++ x\n",
+			want: "\x1b[1m--- a\x1b[0m\n\x1b[1m+++ b\x1b[0m\n\x1b[36m@@ -1 +1 @@\x1b[0m\n\x1b[31m----\x1b[0m\n\x1b[32m+++ x\x1b[0m\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, ColorizeDiff(tt.diff)); diff != "" {
+				t.Errorf("colorized diff mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FuzzyMatch は、pattern を空白で区切った各語が s に含まれるかを判定し、
+// 一致の度合いを表すスコアを返す。各語の文字は s に順に現れればよく、連続している必要はない。
+// 大文字小文字は区別しない。pattern が空の場合は常に一致する。
+func FuzzyMatch(pattern, s string) (score int, ok bool) {
+	target := []rune(s)
+	for _, term := range strings.Fields(strings.ToLower(pattern)) {
+		sc, ok := matchTerm([]rune(term), target)
+		if !ok {
+			return 0, false
+		}
+		score += sc
+	}
+	return score, true
+}
+
+// matchTerm は、term の最初の文字が現れる各位置から貪欲に照合し、最も高いスコアを返す
+func matchTerm(term, target []rune) (int, bool) {
+	best, found := 0, false
+	for start := range target {
+		if unicode.ToLower(target[start]) != term[0] {
+			continue
+		}
+		score, ti, prev := 0, 0, -2
+		for i := start; i < len(target) && ti < len(term); i++ {
+			if unicode.ToLower(target[i]) != term[ti] {
+				continue
+			}
+			score++
+			if i == prev+1 {
+				score += 3 // 連続した一致
+			}
+			if isWordStart(target, i) {
+				score += 2 // 語の先頭での一致
+			}
+			prev = i
+			ti++
+		}
+		if ti == len(term) && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// isWordStart は、target[i] が語 (もしくは camelCase の区切り) の先頭であるかを返す
+func isWordStart(target []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := target[i-1], target[i]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
@@ -0,0 +1,32 @@
+package text
+
+import "testing"
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		ok         bool
+	}{
+		{"", "anything", true},
+		{"dcb", "DocBase CLI", true},
+		{"cli doc", "DocBase CLI", true},
+		{"bd", "DocBase CLI", false},
+		{"作業", "2026-10-19 作業メモ", true},
+		{"作メ", "2026-10-19 作業メモ", true},
+		{"日報", "2026-10-19 作業メモ", false},
+	}
+	for _, tt := range tests {
+		if _, ok := FuzzyMatch(tt.pattern, tt.s); ok != tt.ok {
+			t.Errorf("FuzzyMatch(%q, %q): want %v, got %v", tt.pattern, tt.s, tt.ok, ok)
+		}
+	}
+}
+
+func TestFuzzyMatch_Score(t *testing.T) {
+	// 連続した一致や語の先頭での一致ほどスコアが高い
+	better, _ := FuzzyMatch("base", "DocBase CLI")
+	worse, _ := FuzzyMatch("base", "Bulk Archive SEarch")
+	if better <= worse {
+		t.Errorf("want %d > %d", better, worse)
+	}
+}
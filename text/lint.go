@@ -0,0 +1,219 @@
+package text
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lint の規則の名前
+const (
+	RuleCRLF             = "crlf"              // 改行コードが CRLF
+	RuleTrailingSpace    = "trailing-space"    // 行末の空白
+	RuleBlankLines       = "blank-lines"       // 連続した空行
+	RuleHeadingIncrement = "heading-increment" // 見出しのレベルが２つ以上深くなる
+	RuleLineLength       = "line-length"       // 長すぎる行
+	RuleRequiredSection  = "required-section"  // タグごとに必要な見出しがない
+	RuleBannedWord       = "banned-word"       // 使用を禁止された語
+	RuleImageAlt         = "image-alt"         // 画像の代替テキストがない
+	RuleTodo             = "todo"              // TODO などの作業中の印
+)
+
+// DefaultTodoMarkers は、既定で検出する作業中の印
+var DefaultTodoMarkers = []string{"TODO", "FIXME", "XXX"}
+
+// fixableRules は、LintFix で自動修正できる規則
+var fixableRules = map[string]bool{
+	RuleCRLF:          true,
+	RuleTrailingSpace: true,
+	RuleBlankLines:    true,
+}
+
+// LintConfig は、Lint の規則の設定
+type LintConfig struct {
+	// Disable 無効にする規則の名前
+	Disable []string
+
+	// MaxLineLength 行の最大の表示幅。0 の場合は検査しない
+	MaxLineLength int
+
+	// RequiredSections タグごとに必要な見出し (e.g. {"日報": ["やったこと", "明日やること"]})
+	RequiredSections map[string][]string
+
+	// BannedWords 使用を禁止する語 (大文字小文字は区別しない)
+	BannedWords []string
+
+	// TodoMarkers 検出する作業中の印。省略した場合は DefaultTodoMarkers
+	TodoMarkers []string
+}
+
+func (cfg LintConfig) enabled(rule string) bool {
+	for _, r := range cfg.Disable {
+		if r == rule {
+			return false
+		}
+	}
+	return true
+}
+
+// LintIssue は、Lint で見つかった問題
+type LintIssue struct {
+	Rule    string `json:"rule"`
+	Line    int    `json:"line"` // 1 始まり。文書全体に対する問題は 0
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"`
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%d: [%s] %s", i.Line, i.Rule, i.Message)
+}
+
+var (
+	inlineCode    = regexp.MustCompile("`[^`]*`")
+	imageNoAlt    = regexp.MustCompile(`!\[\s*\]\(`)
+	trailingSpace = regexp.MustCompile(`[ \t]+$`)
+)
+
+// lintLine は、コードブロックの外にある行
+type lintLine struct {
+	no   int // 1 始まり
+	text string
+}
+
+// proseLines は、doc の行のうちコードブロックの外にあるものを返す
+func proseLines(lines []string) []lintLine {
+	var (
+		prose []lintLine
+		fence string
+	)
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if f := fenceOf(trimmed); f != "" && len(line)-len(trimmed) < 4 {
+			fence = f
+			continue
+		}
+		prose = append(prose, lintLine{no: i + 1, text: line})
+	}
+	return prose
+}
+
+// Lint は、Markdown の文書 doc を cfg の規則で検査する。
+// tags は、必要な見出しの検査に使うメモのタグ。
+func Lint(doc string, tags []string, cfg LintConfig) []LintIssue {
+	var issues []LintIssue
+	add := func(rule string, line int, format string, args ...interface{}) {
+		if cfg.enabled(rule) {
+			issues = append(issues, LintIssue{Rule: rule, Line: line, Message: fmt.Sprintf(format, args...), Fixable: fixableRules[rule]})
+		}
+	}
+
+	if i := strings.Index(doc, "\r\n"); i >= 0 {
+		add(RuleCRLF, strings.Count(doc[:i], "\n")+1, "line ends with CRLF")
+	}
+	lines := splitLines(doc)
+	prose := proseLines(lines)
+
+	markers := cfg.TodoMarkers
+	if len(markers) == 0 {
+		markers = DefaultTodoMarkers
+	}
+	quoted := make([]string, len(markers))
+	for i, m := range markers {
+		quoted[i] = regexp.QuoteMeta(m)
+	}
+	todo := regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+
+	prevLevel := 0
+	for i, l := range prose {
+		if trailingSpace.MatchString(l.text) {
+			add(RuleTrailingSpace, l.no, "trailing whitespace")
+		}
+		if strings.TrimSpace(l.text) == "" && i > 0 && prose[i-1].no == l.no-1 && strings.TrimSpace(prose[i-1].text) == "" {
+			add(RuleBlankLines, l.no, "multiple consecutive blank lines")
+		}
+		if level, title, ok := parseHeading(l.text); ok {
+			if prevLevel > 0 && level > prevLevel+1 {
+				add(RuleHeadingIncrement, l.no, "heading %q jumps from level %d to %d", title, prevLevel, level)
+			}
+			prevLevel = level
+		}
+		trimmed := strings.TrimSpace(l.text)
+		if cfg.MaxLineLength > 0 && !strings.HasPrefix(trimmed, "|") && !strings.Contains(l.text, "://") {
+			if w := Width(l.text); w > cfg.MaxLineLength {
+				add(RuleLineLength, l.no, "line is %d columns (max %d)", w, cfg.MaxLineLength)
+			}
+		}
+
+		s := inlineCode.ReplaceAllString(l.text, "")
+		if imageNoAlt.MatchString(s) {
+			add(RuleImageAlt, l.no, "image has no alt text")
+		}
+		lower := strings.ToLower(s)
+		for _, w := range cfg.BannedWords {
+			if w != "" && strings.Contains(lower, strings.ToLower(w)) {
+				add(RuleBannedWord, l.no, "banned word %q", w)
+			}
+		}
+		if m := todo.FindString(s); m != "" {
+			add(RuleTodo, l.no, "%s marker", m)
+		}
+	}
+
+	for _, tag := range tags {
+		for _, heading := range cfg.RequiredSections[tag] {
+			if _, err := FindSection(doc, heading); err != nil {
+				add(RuleRequiredSection, 0, "section %q is required for tag %q", heading, tag)
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// LintFix は、doc の自動修正できる問題を修正したものを返す。
+// cfg で無効にした規則は修正しない。
+func LintFix(doc string, cfg LintConfig) string {
+	crlf := strings.Contains(doc, "\r\n")
+	lines := splitLines(doc)
+	prose := map[int]bool{}
+	for _, l := range proseLines(lines) {
+		prose[l.no-1] = true
+	}
+	fixed := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if prose[i] && cfg.enabled(RuleTrailingSpace) {
+			line = trailingSpace.ReplaceAllString(line, "")
+		}
+		if prose[i] && cfg.enabled(RuleBlankLines) && strings.TrimSpace(line) == "" &&
+			i > 0 && prose[i-1] && strings.TrimSpace(lines[i-1]) == "" {
+			continue
+		}
+		fixed = append(fixed, line)
+	}
+	s := joinLines(fixed)
+	if !strings.HasSuffix(doc, "\n") {
+		s = strings.TrimSuffix(s, "\n")
+	}
+	if crlf && !cfg.enabled(RuleCRLF) {
+		s = strings.ReplaceAll(s, "\n", "\r\n")
+	}
+	return s
+}
+
+// HasFixable は、issues に自動修正できる問題が含まれるかを判定する
+func HasFixable(issues []LintIssue) bool {
+	for _, i := range issues {
+		if i.Fixable {
+			return true
+		}
+	}
+	return false
+}
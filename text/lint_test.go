@@ -0,0 +1,124 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const lintDoc = "# Title\r\n" +
+	"\n" +
+	"### Too deep \n" +
+	"\n" +
+	"\n" +
+	"TODO: write `TODO in code` ![](a.png)\n" +
+	"これは禁止語 Foo を含む長い行です\n" +
+	"```\n" +
+	"code   \n" +
+	"\n" +
+	"\n" +
+	"FIXME in code\n" +
+	"```\n" +
+	"| table | is | skipped | for | length |\n" +
+	"## 作業ログ\n"
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		cfg  LintConfig
+		want []LintIssue
+	}{
+		{
+			name: "default",
+			want: []LintIssue{
+				{Rule: RuleCRLF, Line: 1, Message: "line ends with CRLF", Fixable: true},
+				{Rule: RuleTrailingSpace, Line: 3, Message: "trailing whitespace", Fixable: true},
+				{Rule: RuleHeadingIncrement, Line: 3, Message: `heading "Too deep" jumps from level 1 to 3`},
+				{Rule: RuleBlankLines, Line: 5, Message: "multiple consecutive blank lines", Fixable: true},
+				{Rule: RuleImageAlt, Line: 6, Message: "image has no alt text"},
+				{Rule: RuleTodo, Line: 6, Message: "TODO marker"},
+			},
+		},
+		{
+			name: "configured",
+			tags: []string{"日報", "other"},
+			cfg: LintConfig{
+				Disable:          []string{RuleCRLF, RuleTrailingSpace, RuleBlankLines, RuleHeadingIncrement, RuleImageAlt},
+				MaxLineLength:    30,
+				RequiredSections: map[string][]string{"日報": {"作業ログ", "## 明日やること"}},
+				BannedWords:      []string{"foo"},
+				TodoMarkers:      []string{"FIXME"},
+			},
+			want: []LintIssue{
+				{Rule: RuleRequiredSection, Line: 0, Message: `section "## 明日やること" is required for tag "日報"`},
+				{Rule: RuleLineLength, Line: 6, Message: "line is 37 columns (max 30)"},
+				{Rule: RuleLineLength, Line: 7, Message: "line is 33 columns (max 30)"},
+				{Rule: RuleBannedWord, Line: 7, Message: `banned word "foo"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Lint(lintDoc, tt.tags, tt.cfg)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Lint mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestLintFix(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		cfg  LintConfig
+		want string
+	}{
+		{
+			name: "fix all",
+			doc:  lintDoc,
+			want: "# Title\n\n### Too deep\n\nTODO: write `TODO in code` ![](a.png)\n" +
+				"これは禁止語 Foo を含む長い行です\n```\ncode   \n\n\nFIXME in code\n```\n" +
+				"| table | is | skipped | for | length |\n## 作業ログ\n",
+		},
+		{
+			name: "keep crlf and final line",
+			doc:  "a  \r\n\r\n\r\nb",
+			cfg:  LintConfig{Disable: []string{RuleCRLF}},
+			want: "a\r\n\r\nb",
+		},
+		{
+			name: "no changes",
+			doc:  "a\n\nb",
+			want: "a\n\nb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LintFix(tt.doc, tt.cfg)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("LintFix mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestHasFixable(t *testing.T) {
+	tests := []struct {
+		name   string
+		issues []LintIssue
+		want   bool
+	}{
+		{"no issues", nil, false},
+		{"not fixable", []LintIssue{{Rule: RuleTrailingSpace}}, false},
+		{"fixable", []LintIssue{{Rule: RuleTrailingSpace}, {Rule: RuleCRLF, Fixable: true}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasFixable(tt.issues); got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package text
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrSectionNotFound 指定された見出しが見つからない
+var ErrSectionNotFound = errors.New("section not found")
+
+// Section は、Markdown の ATX 見出し (# Heading) で区切られた区間。
+// 区間は見出し行から、同じかより浅いレベルの次の見出しの直前までで、
+// 下位の見出しを含む。
+type Section struct {
+	Level   int    // 見出しのレベル (1-6)
+	Heading string // 見出し行 (e.g. "## 作業ログ")
+	Title   string // 見出しの文字列 (e.g. "作業ログ")
+	Start   int    // 見出し行の行番号 (0 始まり)
+	End     int    // 区間の終わりの行番号 (この行を含まない)
+}
+
+// ParseSections は、doc に含まれる全ての見出しの区間を出現順に返す。
+// コードブロック内の見出しは無視する。
+func ParseSections(doc string) []Section {
+	lines := splitLines(doc)
+	var (
+		sections []Section
+		fence    string
+	)
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if f := fenceOf(trimmed); f != "" && len(line)-len(trimmed) < 4 {
+			fence = f
+			continue
+		}
+		level, title, ok := parseHeading(line)
+		if !ok {
+			continue
+		}
+		for j := range sections {
+			if sections[j].End < 0 && sections[j].Level >= level {
+				sections[j].End = i
+			}
+		}
+		sections = append(sections, Section{
+			Level:   level,
+			Heading: line,
+			Title:   title,
+			Start:   i,
+			End:     -1,
+		})
+	}
+	for j := range sections {
+		if sections[j].End < 0 {
+			sections[j].End = len(lines)
+		}
+	}
+	return sections
+}
+
+// FindSection は、見出し heading に一致する最初の区間を返す。
+// heading が # で始まる場合は見出し行全体を、そうでない場合は見出しの文字列を比較する。
+func FindSection(doc, heading string) (Section, error) {
+	heading = strings.TrimSpace(heading)
+	for _, s := range ParseSections(doc) {
+		if strings.HasPrefix(heading, "#") {
+			if lv, title, ok := parseHeading(heading); ok && lv == s.Level && title == s.Title {
+				return s, nil
+			}
+			continue
+		}
+		if s.Title == heading {
+			return s, nil
+		}
+	}
+	return Section{}, ErrSectionNotFound
+}
+
+// ReplaceSection は、見出し heading の区間の内容 (見出し行を除く) を content で置き換える
+func ReplaceSection(doc, heading, content string) (string, error) {
+	s, err := FindSection(doc, heading)
+	if err != nil {
+		return "", err
+	}
+	lines := splitLines(doc)
+	replaced := append([]string{}, lines[:s.Start+1]...)
+	replaced = append(replaced, "")
+	replaced = append(replaced, splitLines(strings.Trim(content, "\n"))...)
+	if s.End < len(lines) {
+		replaced = append(replaced, "")
+	}
+	replaced = append(replaced, lines[s.End:]...)
+	return joinLines(replaced), nil
+}
+
+// AppendToSection は、見出し heading の区間の末尾に content を追記する
+func AppendToSection(doc, heading, content string) (string, error) {
+	s, err := FindSection(doc, heading)
+	if err != nil {
+		return "", err
+	}
+	lines := splitLines(doc)
+	end := s.End
+	for end > s.Start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	spliced := append([]string{}, lines[:end]...)
+	spliced = append(spliced, "")
+	spliced = append(spliced, splitLines(strings.Trim(content, "\n"))...)
+	if s.End < len(lines) {
+		spliced = append(spliced, "")
+	}
+	spliced = append(spliced, lines[s.End:]...)
+	return joinLines(spliced), nil
+}
+
+// PrependToSection は、見出し heading の直後に content を挿入する
+func PrependToSection(doc, heading, content string) (string, error) {
+	s, err := FindSection(doc, heading)
+	if err != nil {
+		return "", err
+	}
+	lines := splitLines(doc)
+	start := s.Start + 1
+	for start < s.End && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	spliced := append([]string{}, lines[:s.Start+1]...)
+	spliced = append(spliced, "")
+	spliced = append(spliced, splitLines(strings.Trim(content, "\n"))...)
+	if start < len(lines) {
+		spliced = append(spliced, "")
+	}
+	spliced = append(spliced, lines[start:]...)
+	return joinLines(spliced), nil
+}
+
+// AppendText は、doc の末尾に空行を挟んで content を追記する
+func AppendText(doc, content string) string {
+	doc = strings.TrimRight(Dos2Unix(doc), "\n")
+	content = strings.Trim(content, "\n")
+	if doc == "" {
+		return content + "\n"
+	}
+	return doc + "\n\n" + content + "\n"
+}
+
+// PrependText は、doc の先頭に空行を挟んで content を挿入する
+func PrependText(doc, content string) string {
+	doc = strings.TrimLeft(Dos2Unix(doc), "\n")
+	content = strings.Trim(content, "\n")
+	if doc == "" {
+		return content + "\n"
+	}
+	return content + "\n\n" + doc
+}
+
+// parseHeading は、line が ATX 見出しであればそのレベルと文字列を返す
+func parseHeading(line string) (level int, title string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, "", false
+	}
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	title = strings.TrimSpace(rest)
+	// 末尾の閉じ記号 (## Title ##) を取り除く
+	if t := strings.TrimRight(title, "#"); t != title && (t == "" || strings.HasSuffix(t, " ")) {
+		title = strings.TrimSpace(t)
+	}
+	return level, title, true
+}
+
+// fenceOf は、line がコードブロックの開始であればその記号を返す
+func fenceOf(line string) string {
+	for _, f := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, f) {
+			return f
+		}
+	}
+	return ""
+}
+
+func splitLines(doc string) []string {
+	doc = strings.TrimSuffix(Dos2Unix(doc), "\n")
+	if doc == "" {
+		return []string{}
+	}
+	return strings.Split(doc, "\n")
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,140 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const doc = `intro
+
+# Title
+
+## 作業ログ
+- 09:00 start
+
+### detail
+` + "```sh" + `
+# not a heading
+` + "```" + `
+
+## TODO ##
+- review
+`
+
+func TestParseSections(t *testing.T) {
+	want := []Section{
+		{Level: 1, Heading: "# Title", Title: "Title", Start: 2, End: 14},
+		{Level: 2, Heading: "## 作業ログ", Title: "作業ログ", Start: 4, End: 12},
+		{Level: 3, Heading: "### detail", Title: "detail", Start: 7, End: 12},
+		{Level: 2, Heading: "## TODO ##", Title: "TODO", Start: 12, End: 14},
+	}
+	got := ParseSections(doc)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("sections mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func Test_parseHeading(t *testing.T) {
+	tests := []struct {
+		line  string
+		level int
+		title string
+		ok    bool
+	}{
+		{"# Title", 1, "Title", true},
+		{"###   spaced  ", 3, "spaced", true},
+		{"## closed ##", 2, "closed", true},
+		{"## C#", 2, "C#", true},
+		{"#hashtag", 0, "", false},
+		{"####### seven", 0, "", false},
+		{"    # indented code", 0, "", false},
+		{"#", 1, "", true},
+	}
+	for _, tt := range tests {
+		level, title, ok := parseHeading(tt.line)
+		if level != tt.level || title != tt.title || ok != tt.ok {
+			t.Errorf("parseHeading(%q) = (%d, %q, %v), want (%d, %q, %v)",
+				tt.line, level, title, ok, tt.level, tt.title, tt.ok)
+		}
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	src := "# A\nold\n\n## B\nkeep\n\n# C\nold\n"
+	tests := []struct {
+		name, heading, content, want string
+		wantErr                      error
+	}{
+		{
+			name:    "by title",
+			heading: "A",
+			content: "new\n",
+			want:    "# A\n\nnew\n\n# C\nold\n",
+		},
+		{
+			name:    "by heading line",
+			heading: "## B",
+			content: "replaced",
+			want:    "# A\nold\n\n## B\n\nreplaced\n\n# C\nold\n",
+		},
+		{
+			name:    "last section",
+			heading: "# C",
+			content: "new",
+			want:    "# A\nold\n\n## B\nkeep\n\n# C\n\nnew\n",
+		},
+		{
+			name:    "level mismatch",
+			heading: "## A",
+			wantErr: ErrSectionNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReplaceSection(src, tt.heading, tt.content)
+			if err != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("result mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestAppendToSection(t *testing.T) {
+	src := "# Log\n- a\n\n# Next\n"
+	got, err := AppendToSection(src, "Log", "- b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Log\n- a\n\n- b\n\n# Next\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("result mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestPrependToSection(t *testing.T) {
+	src := "# Log\n\n- a\n# Next\n"
+	got, err := PrependToSection(src, "Log", "- b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Log\n\n- b\n\n- a\n# Next\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("result mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestAppendText(t *testing.T) {
+	if diff := cmp.Diff("body\n\nadded\n", AppendText("body\r\n\r\n", "added")); diff != "" {
+		t.Errorf("AppendText mismatch (-want, +got):%s\n", diff)
+	}
+	if diff := cmp.Diff("added\n", AppendText("", "added\n")); diff != "" {
+		t.Errorf("AppendText mismatch (-want, +got):%s\n", diff)
+	}
+	if diff := cmp.Diff("added\n\nbody\n", PrependText("\nbody\n", "added")); diff != "" {
+		t.Errorf("PrependText mismatch (-want, +got):%s\n", diff)
+	}
+}
@@ -0,0 +1,539 @@
+package text
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style は、Markdown を端末向けに整形する際の配色
+type Style string
+
+const (
+	StyleDark  Style = "dark"
+	StyleLight Style = "light"
+	StyleNoTTY Style = "notty" // 装飾 (ANSI エスケープシーケンス) を使わない
+)
+
+func ParseStyle(s string) (Style, error) {
+	switch st := Style(strings.ToLower(s)); st {
+	case StyleDark, StyleLight, StyleNoTTY:
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown style %q (want dark, light or notty)", s)
+}
+
+type RenderOptions struct {
+	Style Style
+	Width int // 折り返す桁数。0 の場合は折り返さない
+}
+
+type theme struct {
+	Heading, Rule, Quote, Bullet, Check string
+	Code, Link, URL, Table              string
+	Keyword, String, Comment            string
+	Bold, Italic, Strike                string
+}
+
+var themes = map[Style]theme{
+	StyleDark: {
+		Heading: "\x1b[1;36m", Rule: "\x1b[2m", Quote: "\x1b[2;3m", Bullet: "\x1b[36m", Check: "\x1b[32m",
+		Code: "\x1b[33m", Link: "\x1b[4;34m", URL: "\x1b[2m", Table: "\x1b[2m",
+		Keyword: "\x1b[35m", String: "\x1b[32m", Comment: "\x1b[2m",
+		Bold: "\x1b[1m", Italic: "\x1b[3m", Strike: "\x1b[9m",
+	},
+	StyleLight: {
+		Heading: "\x1b[1;34m", Rule: "\x1b[2m", Quote: "\x1b[2;3m", Bullet: "\x1b[34m", Check: "\x1b[32m",
+		Code: "\x1b[31m", Link: "\x1b[4;34m", URL: "\x1b[2m", Table: "\x1b[2m",
+		Keyword: "\x1b[34m", String: "\x1b[32m", Comment: "\x1b[2m",
+		Bold: "\x1b[1m", Italic: "\x1b[3m", Strike: "\x1b[9m",
+	},
+	StyleNoTTY: {},
+}
+
+// RenderMarkdown は、Markdown の文書 doc を端末に表示するために整形する。
+// 見出し・リスト・チェックボックス・引用・表・コードブロック (簡易なシンタックスハイライト付き)・
+// リンクなどを装飾し、opt.Width の桁数で折り返す。
+func RenderMarkdown(doc string, opt RenderOptions) string {
+	r := &renderer{opt: opt, th: themes[opt.Style]}
+	lines := splitLines(doc)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case fenceOf(trimmed) != "" && len(line)-len(trimmed) < 4:
+			fence := fenceOf(trimmed)
+			lang := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			j := i + 1
+			for j < len(lines) && !strings.HasPrefix(strings.TrimLeft(lines[j], " "), fence) {
+				j++
+			}
+			r.codeBlock(lang, lines[i+1:j])
+			i = j
+		case isTableStart(lines, i):
+			j := i + 2
+			for j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "|") {
+				j++
+			}
+			r.table(lines[i], lines[i+1], lines[i+2:j])
+			i = j - 1
+		default:
+			r.line(line)
+		}
+	}
+	return joinLines(r.out)
+}
+
+type renderer struct {
+	opt RenderOptions
+	th  theme
+	out []string
+}
+
+var (
+	reRule     = regexp.MustCompile(`^ {0,3}([-*_])( *[-*_]){2,} *$`)
+	reListItem = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	reCheckbox = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	reTableSep = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+)
+
+func (r *renderer) line(line string) {
+	if level, title, ok := parseHeading(line); ok {
+		r.heading(level, title)
+		return
+	}
+	if m := reRule.FindStringSubmatch(line); m != nil && strings.Count(line, m[1]) >= 3 {
+		r.emit(r.paint(r.th.Rule, strings.Repeat("─", r.ruleWidth())))
+		return
+	}
+	if strings.HasPrefix(strings.TrimLeft(line, " "), ">") {
+		content := strings.TrimPrefix(strings.TrimLeft(strings.TrimLeft(line, " "), ">"), " ")
+		bar := r.paint(r.th.Quote, "│ ")
+		r.block(bar, bar, r.paint(r.th.Quote, r.inline(content, r.th.Quote)))
+		return
+	}
+	if m := reListItem.FindStringSubmatch(line); m != nil {
+		indent := strings.Repeat(" ", len(m[1])/2*2)
+		bullet := m[2]
+		if bullet == "-" || bullet == "*" || bullet == "+" {
+			bullet = "•"
+		}
+		content, color := m[3], r.th.Bullet
+		if c := reCheckbox.FindStringSubmatch(content); c != nil {
+			bullet = "☐"
+			if c[1] != " " {
+				bullet, color = "☑", r.th.Check
+			}
+			content = content[len(c[0]):]
+		}
+		head := indent + r.paint(color, bullet) + " "
+		r.block(head, strings.Repeat(" ", Width(indent+bullet)+1), r.inline(content, ""))
+		return
+	}
+	if strings.TrimSpace(line) == "" {
+		r.emit("")
+		return
+	}
+	r.block("", "", r.inline(line, ""))
+}
+
+func (r *renderer) heading(level int, title string) {
+	title = r.inline(title, r.th.Heading)
+	switch {
+	case level == 1:
+		r.block("", "", r.paint(r.th.Heading, title))
+		r.emit(r.paint(r.th.Heading, strings.Repeat("═", r.ruleWidth())))
+	case level == 2:
+		r.block("", "", r.paint(r.th.Heading, title))
+		r.emit(r.paint(r.th.Heading, strings.Repeat("─", r.ruleWidth())))
+	default:
+		r.block("", "", r.paint(r.th.Heading, strings.Repeat("#", level)+" "+title))
+	}
+}
+
+// block は、s を折り返し、１行目に head を、２行目以降に indent を付けて出力する
+func (r *renderer) block(head, indent, s string) {
+	width := r.opt.Width
+	if width > 0 {
+		width -= VisibleWidth(head)
+	}
+	for i, l := range wrapANSI(s, width) {
+		prefix := head
+		if i > 0 {
+			prefix = indent
+		}
+		r.emit(prefix + l)
+	}
+}
+
+func (r *renderer) codeBlock(lang string, lines []string) {
+	if lang != "" {
+		r.emit(r.paint(r.th.Comment, "  ["+lang+"]"))
+	}
+	for _, l := range lines {
+		l = "    " + r.highlight(lang, strings.ReplaceAll(l, "\t", "    "))
+		// コードは折り返さずに切り詰める
+		if r.opt.Width > 0 && VisibleWidth(l) > r.opt.Width {
+			l, _ = splitANSI(l, r.opt.Width)
+			if sgrState(l) != "" {
+				l += ansiReset
+			}
+		}
+		r.emit(l)
+	}
+}
+
+func (r *renderer) table(header, sep string, rows []string) {
+	cells := [][]string{splitRow(header)}
+	for _, row := range rows {
+		cells = append(cells, splitRow(row))
+	}
+	aligns := splitRow(sep)
+	var cols int
+	for _, row := range cells {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	widths := make([]int, cols)
+	for i, row := range cells {
+		for j := range row {
+			base := ""
+			if i == 0 {
+				base = r.th.Bold
+			}
+			row[j] = r.inline(row[j], base)
+			if i == 0 {
+				row[j] = r.paint(r.th.Bold, row[j])
+			}
+			if w := VisibleWidth(row[j]); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+	border := func(left, mid, right string) string {
+		parts := make([]string, cols)
+		for j := range parts {
+			parts[j] = strings.Repeat("─", widths[j]+2)
+		}
+		return r.paint(r.th.Table, left+strings.Join(parts, mid)+right)
+	}
+	bar := r.paint(r.th.Table, "│")
+	r.emit(border("┌", "┬", "┐"))
+	for i, row := range cells {
+		var sb strings.Builder
+		sb.WriteString(bar)
+		for j := 0; j < cols; j++ {
+			var cell string
+			if j < len(row) {
+				cell = row[j]
+			}
+			pad := widths[j] - VisibleWidth(cell)
+			align := ""
+			if j < len(aligns) {
+				align = aligns[j]
+			}
+			switch {
+			case strings.HasPrefix(align, ":") && strings.HasSuffix(align, ":"):
+				cell = strings.Repeat(" ", pad/2) + cell + strings.Repeat(" ", pad-pad/2)
+			case strings.HasSuffix(align, ":"):
+				cell = strings.Repeat(" ", pad) + cell
+			default:
+				cell += strings.Repeat(" ", pad)
+			}
+			sb.WriteString(" " + cell + " " + bar)
+		}
+		r.emit(sb.String())
+		if i == 0 {
+			r.emit(border("├", "┼", "┤"))
+		}
+	}
+	r.emit(border("└", "┴", "┘"))
+}
+
+func isTableStart(lines []string, i int) bool {
+	return i+1 < len(lines) &&
+		strings.HasPrefix(strings.TrimSpace(lines[i]), "|") &&
+		strings.Contains(lines[i+1], "-") &&
+		reTableSep.MatchString(strings.TrimSpace(lines[i+1]))
+}
+
+func splitRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(strings.TrimSuffix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+func (r *renderer) emit(line string) {
+	r.out = append(r.out, line)
+}
+
+func (r *renderer) ruleWidth() int {
+	if r.opt.Width > 0 {
+		return r.opt.Width
+	}
+	return 40
+}
+
+// paint は、s を code で装飾する。code が空の場合はそのまま返す。
+func (r *renderer) paint(code, s string) string {
+	if code == "" || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+var (
+	reInlineCode = regexp.MustCompile("`[^`]+`")
+	reImage      = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)[^)]*\)`)
+	reLink       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	reBold       = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	reItalic     = regexp.MustCompile(`\*([^*\s][^*]*)\*`)
+	reStrike     = regexp.MustCompile(`~~([^~]+)~~`)
+)
+
+// inline は、行内の装飾 (コード・リンク・強調など) を整形する。
+// 装飾の後には base を付け直し、行全体の装飾を維持する。
+func (r *renderer) inline(s, base string) string {
+	paint := func(code, s string) string {
+		if code == "" {
+			return s
+		}
+		return code + s + ansiReset + base
+	}
+	// コードスパンの中は装飾しない
+	var sb strings.Builder
+	last := 0
+	for _, loc := range reInlineCode.FindAllStringIndex(s, -1) {
+		sb.WriteString(r.inlineText(s[last:loc[0]], paint))
+		sb.WriteString(paint(r.th.Code, s[loc[0]+1:loc[1]-1]))
+		last = loc[1]
+	}
+	sb.WriteString(r.inlineText(s[last:], paint))
+	return sb.String()
+}
+
+func (r *renderer) inlineText(s string, paint func(code, s string) string) string {
+	s = reImage.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reImage.FindStringSubmatch(m)
+		return paint(r.th.Link, "[image: "+sub[1]+"]") + " " + paint(r.th.URL, "("+sub[2]+")")
+	})
+	s = reLink.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reLink.FindStringSubmatch(m)
+		if sub[1] == sub[2] {
+			return paint(r.th.Link, sub[1])
+		}
+		return paint(r.th.Link, sub[1]) + " " + paint(r.th.URL, "("+sub[2]+")")
+	})
+	s = reBold.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reBold.FindStringSubmatch(m)
+		return paint(r.th.Bold, sub[1]+sub[2])
+	})
+	s = reItalic.ReplaceAllStringFunc(s, func(m string) string {
+		return paint(r.th.Italic, m[1:len(m)-1])
+	})
+	s = reStrike.ReplaceAllStringFunc(s, func(m string) string {
+		return paint(r.th.Strike, m[2:len(m)-2])
+	})
+	return s
+}
+
+/***************************************
+ * Syntax highlighting
+ ***************************************/
+
+var keywords = map[string][]string{
+	"go": {"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+		"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return",
+		"select", "struct", "switch", "type", "var", "nil", "true", "false"},
+	"javascript": {"async", "await", "break", "case", "catch", "class", "const", "continue", "default",
+		"else", "export", "extends", "false", "finally", "for", "function", "if", "import", "in", "let",
+		"new", "null", "of", "return", "switch", "this", "throw", "true", "try", "typeof", "undefined",
+		"var", "while", "interface", "type"},
+	"python": {"and", "as", "class", "def", "elif", "else", "except", "False", "finally", "for", "from",
+		"if", "import", "in", "is", "lambda", "None", "not", "or", "pass", "raise", "return", "True",
+		"try", "while", "with", "yield"},
+	"ruby": {"begin", "class", "def", "do", "else", "elsif", "end", "ensure", "false", "if", "module",
+		"nil", "require", "rescue", "return", "self", "true", "unless", "until", "when", "while", "yield"},
+	"shell": {"case", "do", "done", "elif", "else", "esac", "export", "fi", "for", "function", "if",
+		"in", "local", "return", "then", "while"},
+	"sql": {"and", "as", "by", "create", "delete", "from", "group", "insert", "into", "join", "left",
+		"limit", "not", "null", "on", "or", "order", "select", "set", "table", "update", "values", "where"},
+}
+
+var langAliases = map[string]string{
+	"golang": "go", "js": "javascript", "ts": "javascript", "typescript": "javascript",
+	"py": "python", "rb": "ruby", "sh": "shell", "bash": "shell", "zsh": "shell", "console": "shell",
+}
+
+// lineComments は、言語ごとの行コメントの開始記号
+var lineComments = map[string]string{
+	"go": "//", "javascript": "//", "python": "#", "ruby": "#", "shell": "#", "sql": "--",
+}
+
+// highlight は、コードの１行をキーワード・文字列・コメントで色分けする。
+// 未知の言語 (mermaid, plantuml など) はそのまま返す。
+func (r *renderer) highlight(lang, line string) string {
+	lang = strings.ToLower(lang)
+	if alias, ok := langAliases[lang]; ok {
+		lang = alias
+	}
+	kws, ok := keywords[lang]
+	if !ok || r.opt.Style == StyleNoTTY {
+		return line
+	}
+	isKeyword := func(w string) bool {
+		if lang == "sql" {
+			w = strings.ToLower(w)
+		}
+		for _, kw := range kws {
+			if kw == w {
+				return true
+			}
+		}
+		return false
+	}
+	comment := lineComments[lang]
+	var sb strings.Builder
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case comment != "" && strings.HasPrefix(line[i:], comment):
+			sb.WriteString(r.paint(r.th.Comment, line[i:]))
+			return sb.String()
+		case c == '"' || c == '\'' || c == '`':
+			j := i + 1
+			for j < len(line) && line[j] != c {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(line) {
+				j++
+			} else {
+				j = len(line)
+			}
+			sb.WriteString(r.paint(r.th.String, line[i:j]))
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(line) && (isIdentStart(line[j]) || '0' <= line[j] && line[j] <= '9') {
+				j++
+			}
+			if w := line[i:j]; isKeyword(w) {
+				sb.WriteString(r.paint(r.th.Keyword, w))
+			} else {
+				sb.WriteString(w)
+			}
+			i = j
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+/***************************************
+ * ANSI aware wrapping
+ ***************************************/
+
+var reANSI = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// StripANSI は、s から ANSI エスケープシーケンス (SGR) を取り除く
+func StripANSI(s string) string {
+	return reANSI.ReplaceAllString(s, "")
+}
+
+// VisibleWidth は、ANSI エスケープシーケンスを除いた s の表示幅を返す
+func VisibleWidth(s string) int {
+	return Width(StripANSI(s))
+}
+
+// wrapANSI は、ANSI エスケープシーケンスを含む s を空白の位置で折り返す。
+// 行をまたぐ装飾は、行末で解除して次の行の先頭で付け直す。
+func wrapANSI(s string, width int) []string {
+	if width <= 0 || VisibleWidth(s) <= width {
+		return []string{s}
+	}
+	var (
+		lines []string
+		line  string
+		lw    int
+	)
+	flush := func() {
+		lines = append(lines, line)
+		line, lw = "", 0
+	}
+	for _, word := range strings.Split(s, " ") {
+		ww := VisibleWidth(word)
+		if lw > 0 && lw+1+ww > width {
+			flush()
+		}
+		if lw > 0 {
+			line += " "
+			lw++
+		}
+		for ww > width-lw {
+			head, rest := splitANSI(word, width-lw)
+			line += head
+			flush()
+			word, ww = rest, VisibleWidth(rest)
+		}
+		line += word
+		lw += ww
+	}
+	flush()
+
+	var state string
+	for i := range lines {
+		lines[i] = state + lines[i]
+		state = sgrState(lines[i])
+		if state != "" {
+			lines[i] += ansiReset
+		}
+	}
+	return lines
+}
+
+// splitANSI は、s を表示幅 width までの部分と残りに分ける。先頭の１文字は必ず含める。
+func splitANSI(s string, width int) (string, string) {
+	var w int
+	for i := 0; i < len(s); {
+		if loc := reANSI.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+			i += loc[1]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := RuneWidth(r)
+		if w+rw > width && w > 0 {
+			return s[:i], s[i:]
+		}
+		w += rw
+		i += size
+	}
+	return s, ""
+}
+
+// sgrState は、行末の時点で有効な装飾のエスケープシーケンスを返す
+func sgrState(line string) string {
+	var state string
+	for _, seq := range reANSI.FindAllString(line, -1) {
+		if seq == ansiReset {
+			state = ""
+		} else {
+			state += seq
+		}
+	}
+	return state
+}
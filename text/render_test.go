@@ -0,0 +1,86 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "headings",
+			doc:  "# Title\n## Sub\n### Small",
+			want: []string{"Title", strings.Repeat("═", 20), "Sub", strings.Repeat("─", 20), "### Small"},
+		},
+		{
+			name: "inline",
+			doc:  "**bold** `*code*`\n[link](https://e.x)",
+			want: []string{"bold *code*", "link (https://e.x)"},
+		},
+		{
+			name: "lists",
+			doc:  "- item\n  1. nested\n- [ ] todo\n- [x] done",
+			want: []string{"• item", "  1. nested", "☐ todo", "☑ done"},
+		},
+		{
+			name: "wrap list item",
+			doc:  "- one two three four five six",
+			want: []string{"• one two three four", "  five six"},
+		},
+		{
+			name: "quote",
+			doc:  "> quoted",
+			want: []string{"│ quoted"},
+		},
+		{
+			name: "table",
+			doc:  "| name | n |\n|------|--:|\n| 作業 | 1 |",
+			want: []string{
+				"┌──────┬───┐",
+				"│ name │ n │",
+				"├──────┼───┤",
+				"│ 作業 │ 1 │",
+				"└──────┴───┘",
+			},
+		},
+		{
+			name: "code block is not decorated",
+			doc:  "```mermaid\nA-->**B**\n```",
+			want: []string{"  [mermaid]", "    A-->**B**"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderMarkdown(tt.doc, RenderOptions{Style: StyleNoTTY, Width: 20})
+			if diff := cmp.Diff(strings.Join(tt.want, "\n")+"\n", got); diff != "" {
+				t.Errorf("rendered mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown_Highlight(t *testing.T) {
+	got := RenderMarkdown("```go\nreturn \"ok\" // done\n```", RenderOptions{Style: StyleDark})
+	want := "    \x1b[35mreturn\x1b[0m \x1b[32m\"ok\"\x1b[0m \x1b[2m// done\x1b[0m\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("want suffix %q, got %q", want, got)
+	}
+}
+
+func TestWrapANSI(t *testing.T) {
+	got := wrapANSI("plain \x1b[1mbold text here\x1b[0m end", 10)
+	want := []string{
+		"plain \x1b[1mbold\x1b[0m",
+		"\x1b[1mtext here\x1b[0m",
+		"end",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("lines mismatch (-want, +got):%s\n", diff)
+	}
+}
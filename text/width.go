@@ -0,0 +1,89 @@
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// wideRanges 端末上で２桁分の幅で表示される文字の範囲 (East Asian Wide/Fullwidth の主なもの)
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x3FFFD},
+}
+
+// RuneWidth は、r を端末に表示したときの桁数を返す
+func RuneWidth(r rune) int {
+	if r < 0x20 || r == 0x7F {
+		return 0
+	}
+	for _, rg := range wideRanges {
+		if r >= rg[0] && r <= rg[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// Width は、s を端末に表示したときの桁数を返す
+func Width(s string) int {
+	var w int
+	for _, r := range s {
+		w += RuneWidth(r)
+	}
+	return w
+}
+
+// Truncate は、s を端末に表示したときの桁数が width 以下になるように切り詰める
+func Truncate(s string, width int) string {
+	var w int
+	for i, r := range s {
+		w += RuneWidth(r)
+		if w > width {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// PadRight は、s の右側を空白で埋めて width 桁にする。s は切り詰めない。
+func PadRight(s string, width int) string {
+	for w := Width(s); w < width; w++ {
+		s += " "
+	}
+	return s
+}
+
+// Wrap は、line を端末に表示したときの桁数が width 以下になるように折り返す。
+// 可能であれば空白の位置で折り返す。
+func Wrap(line string, width int) []string {
+	if width <= 0 {
+		return []string{line}
+	}
+	var lines []string
+	for Width(line) > width {
+		cut := len(Truncate(line, width))
+		if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(line)
+		}
+		if line[cut] != ' ' {
+			if sp := strings.LastIndexByte(line[:cut], ' '); sp > 0 {
+				cut = sp + 1
+			}
+		}
+		lines = append(lines, strings.TrimRight(line[:cut], " "))
+		line = strings.TrimLeft(line[cut:], " ")
+	}
+	return append(lines, line)
+}
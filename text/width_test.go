@@ -0,0 +1,57 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWidth(t *testing.T) {
+	for s, want := range map[string]int{
+		"":              0,
+		"docbase":       7,
+		"作業メモ":          8,
+		"2026-10-19 日報": 15,
+	} {
+		if got := Width(s); got != want {
+			t.Errorf("Width(%q): want %d, got %d", s, want, got)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"docbase", 10, "docbase"},
+		{"docbase", 3, "doc"},
+		{"作業メモ", 5, "作業"},
+		{"作業メモ", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.s, tt.width); got != tt.want {
+			t.Errorf("Truncate(%q, %d): want %q, got %q", tt.s, tt.width, tt.want, got)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  []string
+	}{
+		{"docbase cli", 20, []string{"docbase cli"}},
+		{"docbase command line interface", 12, []string{"docbase", "command line", "interface"}},
+		{"作業メモを書く", 6, []string{"作業メ", "モを書", "く"}},
+		{"", 10, []string{""}},
+	}
+	for _, tt := range tests {
+		got := Wrap(tt.line, tt.width)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Wrap(%q, %d) mismatch (-want, +got):%s\n", tt.line, tt.width, diff)
+		}
+	}
+}
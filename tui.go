@@ -0,0 +1,521 @@
+package docbasecli
+
+// 全画面の TUI (docbase tui)
+//
+// 左にタグ・グループによる絞り込み、右上にメモの一覧、右下に本文を表示する。
+// メモの取得・アーカイブ・コメントは CLI と同じリクエストとハンドラを使う。
+//
+//	Tab: 絞り込み/一覧の切り替え  j/k: カーソル移動  Enter: 絞り込みの適用
+//	J/K/Space: 本文のスクロール  n/p: 次/前のページ  /: 検索  r: 再読み込み
+//	o: ブラウザで開く  e: 編集  a: アーカイブ/解除  c: コメント  q: 終了
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/micheam/docbase-cli/pointer"
+	"github.com/micheam/docbase-cli/text"
+	"github.com/micheam/go-docbase"
+)
+
+type TUIRequest struct {
+	Domain  string
+	Query   string // 初期の検索クエリ
+	PerPage int    // 省略した場合は 50 件ずつ表示する
+
+	// Edit は、メモを編集する。端末を通常のモードに戻してから呼び出される
+	Edit PostHandler
+	// Open は、メモをブラウザで開く。省略した場合は OpenBrowser
+	Open PostHandler
+}
+
+// RunTUI は、端末を全画面の TUI に切り替え、q が押されるまでキー入力を処理する
+func RunTUI(ctx context.Context, req TUIRequest) error {
+	if req.PerPage == 0 {
+		req.PerPage = 50
+	}
+	if req.Open == nil {
+		req.Open = OpenBrowser
+	}
+	tty, err := openTTY()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tty.Close() }()
+
+	t := &tui{req: req, tty: tty, query: req.Query, page: 1}
+	if err := t.enter(); err != nil {
+		return err
+	}
+	defer func() { _ = t.leave() }()
+
+	t.render()
+	t.loadFilters(ctx)
+	t.load(ctx)
+	buf := make([]byte, 256)
+	for !t.quit {
+		t.render()
+		n, err := tty.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			t.handle(ctx, k)
+			if t.quit {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+type tuiFocus int
+
+const (
+	focusList tuiFocus = iota
+	focusSidebar
+)
+
+// tuiFilter は、サイドバーの項目
+type tuiFilter struct {
+	Label  string
+	Query  string // e.g. tag:release
+	Header bool   // 選択できない見出し
+}
+
+type tui struct {
+	req     TUIRequest
+	tty     *os.File
+	restore func() error
+
+	filters                []tuiFilter
+	filter                 int // 適用中の絞り込み
+	sideCursor, sideOffset int
+	focus                  tuiFocus
+
+	query          string
+	page           int
+	posts          []docbase.Post
+	meta           docbase.Meta
+	cursor, offset int
+	scroll         int // 本文のスクロール位置
+
+	// 入力欄 (検索やコメント)
+	inputting   bool
+	inputPrompt string
+	input       []rune
+	onSubmit    func(ctx context.Context, s string)
+
+	message string
+	quit    bool
+}
+
+// enter は、端末を raw モードにして代替スクリーンに切り替える
+func (t *tui) enter() error {
+	restore, err := makeRaw(int(t.tty.Fd()))
+	if err != nil {
+		return err
+	}
+	t.restore = restore
+	fmt.Fprint(t.tty, "\x1b[?1049h\x1b[?25l")
+	return nil
+}
+
+// leave は、端末を元の状態に戻す
+func (t *tui) leave() error {
+	fmt.Fprint(t.tty, "\x1b[?25h\x1b[?1049l")
+	return t.restore()
+}
+
+// suspend は、端末を元の状態に戻して fn を実行する
+func (t *tui) suspend(fn func() error) error {
+	if err := t.leave(); err != nil {
+		return err
+	}
+	ferr := fn()
+	if err := t.enter(); err != nil {
+		return err
+	}
+	return ferr
+}
+
+func (t *tui) searchQuery() string {
+	q := t.query
+	if t.filter < len(t.filters) && t.filters[t.filter].Query != "" {
+		q = strings.TrimSpace(q + " " + t.filters[t.filter].Query)
+	}
+	return q
+}
+
+// load は、現在の検索クエリとページでメモの一覧を取得し直す
+func (t *tui) load(ctx context.Context) {
+	t.message = "loading..."
+	t.render()
+	req := ListPostsRequest{
+		Domain:  t.req.Domain,
+		Query:   pointer.StringPtr(t.searchQuery()),
+		Page:    pointer.IntPtr(t.page),
+		PerPage: pointer.IntPtr(t.req.PerPage),
+	}
+	err := ListPosts(ctx, req, func(_ context.Context, ps []docbase.Post, m docbase.Meta) error {
+		t.posts, t.meta = ps, m
+		return nil
+	})
+	if err != nil {
+		t.message = err.Error()
+		return
+	}
+	t.message = ""
+	t.cursor, t.offset, t.scroll = 0, 0, 0
+}
+
+// loadFilters は、サイドバーに表示するタグとグループを取得する
+func (t *tui) loadFilters(ctx context.Context) {
+	filters := []tuiFilter{{Label: "All posts"}}
+	err := ListAllGroups(ctx, ListGroupsRequest{Domain: t.req.Domain}, func(_ context.Context, groups []Group) error {
+		filters = append(filters, tuiFilter{Label: "Groups", Header: true})
+		for _, g := range groups {
+			filters = append(filters, tuiFilter{Label: g.Name, Query: "group:" + QuoteQuery(g.Name)})
+		}
+		return nil
+	})
+	if err != nil {
+		t.message = err.Error()
+	}
+	err = ListTags(ctx, ListTagsRequest{Domain: t.req.Domain}, func(_ context.Context, tags []docbase.Tag) error {
+		filters = append(filters, tuiFilter{Label: "Tags", Header: true})
+		for _, tag := range tags {
+			filters = append(filters, tuiFilter{Label: "#" + tag.Name, Query: "tag:" + QuoteQuery(tag.Name)})
+		}
+		return nil
+	})
+	if err != nil {
+		t.message = err.Error()
+	}
+	t.filters = filters
+}
+
+func (t *tui) current() *docbase.Post {
+	if t.cursor < len(t.posts) {
+		return &t.posts[t.cursor]
+	}
+	return nil
+}
+
+// prompt は、入力欄を表示して入力された文字列で submit を呼び出す
+func (t *tui) prompt(label string, submit func(ctx context.Context, s string)) {
+	t.inputting, t.inputPrompt, t.input, t.onSubmit = true, label, nil, submit
+}
+
+func (t *tui) handle(ctx context.Context, k key) {
+	if t.inputting {
+		t.handleInput(ctx, k)
+		return
+	}
+	t.message = ""
+	switch {
+	case k.kind == keyCancel, k.kind == keyRune && k.r == 'q':
+		t.quit = true
+	case k.kind == keyToggle:
+		if t.focus == focusList {
+			t.focus = focusSidebar
+		} else {
+			t.focus = focusList
+		}
+	case k.kind == keyUp, k.kind == keyRune && k.r == 'k':
+		t.move(-1)
+	case k.kind == keyDown, k.kind == keyRune && k.r == 'j':
+		t.move(1)
+	case k.kind == keyEnter && t.focus == focusSidebar:
+		if t.sideCursor < len(t.filters) && !t.filters[t.sideCursor].Header {
+			t.filter, t.page, t.focus = t.sideCursor, 1, focusList
+			t.load(ctx)
+		}
+	case k.kind != keyRune:
+	case k.r == 'J', k.r == ' ':
+		step := 1
+		if k.r == ' ' {
+			step = 10
+		}
+		t.scroll += step
+	case k.r == 'K':
+		if t.scroll > 0 {
+			t.scroll--
+		}
+	case k.r == 'n':
+		if t.meta.NextPageURL != "" {
+			t.page++
+			t.load(ctx)
+		}
+	case k.r == 'p':
+		if t.page > 1 {
+			t.page--
+			t.load(ctx)
+		}
+	case k.r == 'r':
+		t.load(ctx)
+	case k.r == '/':
+		t.prompt("Search: ", func(ctx context.Context, s string) {
+			t.query, t.page = s, 1
+			t.load(ctx)
+		})
+		t.input = []rune(t.query)
+	case t.current() == nil:
+	case k.r == 'o':
+		t.run(t.req.Open(ctx, *t.current()))
+	case k.r == 'e' && t.req.Edit != nil:
+		t.edit(ctx)
+	case k.r == 'a':
+		t.archive(ctx)
+	case k.r == 'c':
+		t.prompt("Comment: ", t.comment)
+	}
+}
+
+func (t *tui) handleInput(ctx context.Context, k key) {
+	switch k.kind {
+	case keyRune:
+		t.input = append(t.input, k.r)
+	case keyBackspace:
+		if len(t.input) > 0 {
+			t.input = t.input[:len(t.input)-1]
+		}
+	case keyClear:
+		t.input = nil
+	case keyCancel:
+		t.inputting = false
+	case keyEnter:
+		t.inputting = false
+		t.onSubmit(ctx, string(t.input))
+	}
+}
+
+func (t *tui) move(delta int) {
+	if t.focus == focusSidebar {
+		for i := t.sideCursor + delta; 0 <= i && i < len(t.filters); i += delta {
+			if !t.filters[i].Header {
+				t.sideCursor = i
+				return
+			}
+		}
+		return
+	}
+	if i := t.cursor + delta; 0 <= i && i < len(t.posts) {
+		t.cursor, t.scroll = i, 0
+	}
+}
+
+// run は、操作の結果をステータス行に表示する
+func (t *tui) run(err error) {
+	if err != nil {
+		t.message = err.Error()
+	}
+}
+
+func (t *tui) edit(ctx context.Context) {
+	post := *t.current()
+	err := t.suspend(func() error {
+		return t.req.Edit(ctx, post)
+	})
+	if err != nil {
+		t.message = err.Error()
+		return
+	}
+	// 更新後のメモで一覧を差し替える
+	cursor := t.cursor
+	t.run(GetPost(ctx, GetPostRequest{Domain: t.req.Domain, ID: post.ID}, func(_ context.Context, p docbase.Post) error {
+		t.posts[cursor] = p
+		return nil
+	}))
+}
+
+func (t *tui) archive(ctx context.Context) {
+	post := t.current()
+	verb := "Archive"
+	if post.Archived {
+		verb = "Unarchive"
+	}
+	t.prompt(fmt.Sprintf("%s %q? [y/N]: ", verb, post.Title), func(ctx context.Context, s string) {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "y" && s != "yes" {
+			return
+		}
+		req := ArchivePostRequest{Domain: t.req.Domain, ID: post.ID}
+		var err error
+		if post.Archived {
+			err = UnarchivePost(ctx, req)
+		} else {
+			err = ArchivePost(ctx, req)
+		}
+		if err != nil {
+			t.message = err.Error()
+			return
+		}
+		post.Archived = !post.Archived
+		t.message = verb + "d."
+	})
+}
+
+func (t *tui) comment(ctx context.Context, s string) {
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+	req := CreateCommentRequest{Domain: t.req.Domain, ID: t.current().ID, Body: s}
+	t.run(CreateComment(ctx, req, func(_ context.Context, _ Comment) error {
+		t.message = "Commented."
+		return nil
+	}))
+}
+
+/***************************************
+ * Rendering
+ ***************************************/
+
+func (t *tui) render() {
+	width, height, err := terminalSize(int(t.tty.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	buf := new(bytes.Buffer)
+	t.draw(buf, width, height)
+	_, _ = t.tty.Write(buf.Bytes())
+}
+
+// draw は、width x height の画面を out に描画する。
+// 最後の行の後に改行すると画面がスクロールするため、改行は行の間にのみ出力する。
+func (t *tui) draw(out io.Writer, width, height int) {
+	fmt.Fprint(out, "\x1b[H")
+	first := true
+	line := func(s string) {
+		if !first {
+			fmt.Fprint(out, "\r\n")
+		}
+		first = false
+		fmt.Fprint(out, s+"\x1b[K")
+	}
+	if width < 40 || height < 10 {
+		line("terminal too small")
+		fmt.Fprint(out, "\x1b[J")
+		return
+	}
+
+	header := fmt.Sprintf(" docbase: %s  query: %s  page %d (%d/%d)",
+		t.req.Domain, t.searchQuery(), t.page, len(t.posts), t.meta.Total)
+	line(reverse(text.PadRight(text.Truncate(header, width), width)))
+
+	var (
+		bodyHeight  = height - 2
+		sideWidth   = clamp(width/4, 16, 30)
+		mainWidth   = width - sideWidth - 1
+		listHeight  = bodyHeight / 2
+		side        = t.sidebarLines(sideWidth, bodyHeight)
+		list        = t.listLines(mainWidth, listHeight)
+		previewLine = t.previewLines(mainWidth, bodyHeight-listHeight-1)
+	)
+	main := append(list, strings.Repeat("─", mainWidth))
+	main = append(main, previewLine...)
+	for i := 0; i < bodyHeight; i++ {
+		line(side[i] + "│" + main[i])
+	}
+
+	switch {
+	case t.inputting:
+		line(t.inputPrompt + string(t.input))
+	case t.message != "":
+		line(text.Truncate(t.message, width))
+	default:
+		line(text.Truncate("Tab:focus j/k:move /:search n/p:page o:open e:edit a:archive c:comment q:quit", width))
+	}
+	fmt.Fprint(out, "\x1b[J")
+}
+
+func (t *tui) sidebarLines(width, height int) []string {
+	if t.sideCursor < t.sideOffset {
+		t.sideOffset = t.sideCursor
+	}
+	if t.sideCursor >= t.sideOffset+height {
+		t.sideOffset = t.sideCursor - height + 1
+	}
+	lines := make([]string, height)
+	for i := range lines {
+		j := t.sideOffset + i
+		if j >= len(t.filters) {
+			lines[i] = strings.Repeat(" ", width)
+			continue
+		}
+		f := t.filters[j]
+		label := " " + f.Label
+		if f.Header {
+			label = "[" + f.Label + "]"
+		} else if j == t.filter {
+			label = "*" + f.Label
+		}
+		s := text.PadRight(text.Truncate(label, width), width)
+		if j == t.sideCursor && t.focus == focusSidebar {
+			s = reverse(s)
+		}
+		lines[i] = s
+	}
+	return lines
+}
+
+func (t *tui) listLines(width, height int) []string {
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+height {
+		t.offset = t.cursor - height + 1
+	}
+	lines := make([]string, height)
+	for i := range lines {
+		j := t.offset + i
+		if j >= len(t.posts) {
+			continue
+		}
+		s := text.Truncate(fmt.Sprintf(" %d %s", t.posts[j].ID, pickerLabel(t.posts[j])), width)
+		if j == t.cursor {
+			s = text.PadRight(s, width)
+			if t.focus == focusList {
+				s = reverse(s)
+			}
+		}
+		lines[i] = s
+	}
+	return lines
+}
+
+func (t *tui) previewLines(width, height int) []string {
+	var all []string
+	if post := t.current(); post != nil {
+		all = append(all, "\x1b[1m"+text.Truncate(post.Title, width)+"\x1b[0m")
+		all = append(all, text.Truncate(fmt.Sprintf("@%s  updated: %s", post.User.Name, post.UpdatedAt), width), "")
+		body := text.RenderMarkdown(post.Body, text.RenderOptions{Style: text.StyleDark, Width: width})
+		all = append(all, strings.Split(strings.TrimSuffix(body, "\n"), "\n")...)
+	}
+	if max := len(all) - height; t.scroll > max {
+		t.scroll = max
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+	lines := make([]string, height)
+	copy(lines, all[t.scroll:])
+	return lines
+}
+
+func reverse(s string) string {
+	return "\x1b[7m" + s + "\x1b[0m"
+}
+
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
@@ -0,0 +1,75 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/micheam/go-docbase"
+)
+
+func TestTUI(t *testing.T) {
+	ui := &tui{
+		req:   TUIRequest{Domain: "example"},
+		query: "author:micheam",
+		page:  1,
+		filters: []tuiFilter{
+			{Label: "All posts"},
+			{Label: "Tags", Header: true},
+			{Label: "#release", Query: "tag:release"},
+		},
+		posts: []docbase.Post{
+			{ID: 1, Title: "リリース手順", Body: "## 手順\n1. tag を打つ"},
+			{ID: 2, Title: "2026-10-19 作業メモ"},
+		},
+		meta: docbase.Meta{Total: 2},
+	}
+	ctx := context.Background()
+
+	ui.handle(ctx, key{kind: keyRune, r: 'j'})
+	if ui.cursor != 1 {
+		t.Errorf("want cursor 1, got %d", ui.cursor)
+	}
+	ui.handle(ctx, key{kind: keyRune, r: 'j'})
+	if ui.cursor != 1 {
+		t.Errorf("cursor must not exceed posts, got %d", ui.cursor)
+	}
+
+	// サイドバーでは見出しを飛ばして移動する
+	ui.handle(ctx, key{kind: keyToggle})
+	ui.handle(ctx, key{kind: keyDown})
+	if ui.focus != focusSidebar || ui.sideCursor != 2 {
+		t.Errorf("want sidebar cursor 2, got focus=%d cursor=%d", ui.focus, ui.sideCursor)
+	}
+	ui.filter = ui.sideCursor
+	if got, want := ui.searchQuery(), "author:micheam tag:release"; got != want {
+		t.Errorf("want query %q, got %q", want, got)
+	}
+
+	ui.handle(ctx, key{kind: keyToggle})
+	ui.handle(ctx, key{kind: keyUp})
+	buf := new(bytes.Buffer)
+	ui.draw(buf, 80, 20)
+	for _, want := range []string{"query: author:micheam tag:release", "*#release", "1 リリース手順", "tag を打つ"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in screen:\n%s", want, buf.String())
+		}
+	}
+
+	// 最後の行の後に改行すると画面がスクロールしてヘッダが消える
+	if got := strings.Count(buf.String(), "\r\n") + 1; got != 20 {
+		t.Errorf("want 20 lines, got %d", got)
+	}
+	// ブラウザを開けない場合は終了せずにステータス行に表示する
+	ui.req.Open = func(context.Context, docbase.Post) error { return errors.New("failed to open browser") }
+	ui.handle(ctx, key{kind: keyRune, r: 'o'})
+	if ui.message != "failed to open browser" {
+		t.Errorf("want error message, got %q", ui.message)
+	}
+	ui.handle(ctx, key{kind: keyRune, r: 'q'})
+	if !ui.quit {
+		t.Error("want quit")
+	}
+}
@@ -0,0 +1,286 @@
+package docbasecli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Member は DocBase チームに所属するユーザー
+type Member struct {
+	ID                    int     `json:"id"`
+	Name                  string  `json:"name"`
+	Username              string  `json:"username"`
+	ProfileImageURL       string  `json:"profile_image_url,omitempty"`
+	Role                  string  `json:"role,omitempty"`
+	PostsCount            int     `json:"posts_count,omitempty"`
+	LastAccessTime        string  `json:"last_access_time,omitempty"`
+	TwoStepAuthentication bool    `json:"two_step_authentication"`
+	Groups                []Group `json:"groups,omitempty"`
+}
+
+// Team は、アクセストークンで操作可能なチーム
+type Team struct {
+	Domain string `json:"domain"`
+	Name   string `json:"name"`
+}
+
+// define ResultHandlers
+type (
+	MemberHandler           func(ctx context.Context, m Member) error
+	MemberCollectionHandler func(ctx context.Context, ms []Member) error
+)
+
+/***************************************
+ * List Users
+ ***************************************/
+
+type ListUsersRequest struct {
+	Domain string
+	// Query ユーザー名・ユーザーIDの部分一致で絞り込む
+	Query   *string
+	Page    *int
+	PerPage *int
+}
+
+// MaxUsersPerPage ユーザー検索APIで１ページあたりに取得できる最大件数
+const MaxUsersPerPage = 100
+
+func ListUsers(ctx context.Context, req ListUsersRequest, handle MemberCollectionHandler) error {
+	log.Printf("list users with req: %v", req)
+	users, err := listUsers(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	return handle(ctx, users)
+}
+
+// ListAllUsers は、全ページを取得してからまとめて handle を呼び出す
+func ListAllUsers(ctx context.Context, req ListUsersRequest, handle MemberCollectionHandler) error {
+	var all []Member
+	perPage := MaxUsersPerPage
+	req.PerPage = &perPage
+	for page := 1; ; page++ {
+		p := page
+		req.Page = &p
+		users, err := listUsers(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+		all = append(all, users...)
+		if len(users) < perPage {
+			break
+		}
+	}
+	return handle(ctx, all)
+}
+
+func listUsers(ctx context.Context, req ListUsersRequest) ([]Member, error) {
+	param := url.Values{}
+	param.Add("include_user_groups", "true")
+	if req.Query != nil {
+		param.Add("q", *req.Query)
+	}
+	if req.Page != nil {
+		param.Add("page", fmt.Sprint(*req.Page))
+	}
+	if req.PerPage != nil {
+		param.Add("per_page", fmt.Sprint(*req.PerPage))
+	}
+	r, err := newRequest(ctx, http.MethodGet, buildURL("teams", req.Domain, "users"), nil, param)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]Member, 0)
+	err = RetryOnRateLimit(ctx, func() error { return doRequest(r, &users) })
+	return users, err
+}
+
+// ResolveUser は、ユーザーID (username) もしくは数値の ID からユーザーを特定する
+func ResolveUser(ctx context.Context, domain, username string) (*Member, error) {
+	var found *Member
+	id, convErr := strconv.Atoi(username)
+	req := ListUsersRequest{Domain: domain, Query: &username}
+	if convErr == nil {
+		// 検索クエリは名前で絞り込むため、数値の ID では全ユーザーから探す
+		req.Query = nil
+	}
+	err := ListAllUsers(ctx, req, func(_ context.Context, users []Member) error {
+		for i := range users {
+			if users[i].Username == username || (convErr == nil && users[i].ID == id) {
+				found = &users[i]
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("user %q not found", username)
+	}
+	return found, nil
+}
+
+/***************************************
+ * Whoami
+ ***************************************/
+
+// Identity は、アクセストークンとそれに紐づく利用者の情報
+type Identity struct {
+	Teams     []Team     `json:"teams"`
+	Team      *Team      `json:"team,omitempty"`
+	User      *Member    `json:"user,omitempty"`
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+}
+
+type IdentityHandler func(ctx context.Context, id Identity) error
+
+type WhoamiRequest struct {
+	Domain string
+	// UserID 設定ファイルで指定されたユーザーID (省略可)
+	UserID string
+}
+
+// Whoami は、アクセストークンが有効であることを確認し、
+// 操作対象のチームとユーザー、レートリミットの状態を返す。
+// DocBase API にはトークンの所有者を直接取得する手段がないため、
+// ユーザーは設定ファイルの UserID から解決する。
+func Whoami(ctx context.Context, req WhoamiRequest, handle IdentityHandler) error {
+	r, err := newRequest(ctx, http.MethodGet, buildURL("teams"), nil, nil)
+	if err != nil {
+		return err
+	}
+	var id Identity
+	if err := doRequest(r, &id.Teams); err != nil {
+		return fmt.Errorf("failed to validate access token: %w", err)
+	}
+	for i := range id.Teams {
+		if id.Teams[i].Domain == req.Domain {
+			id.Team = &id.Teams[i]
+		}
+	}
+	if req.UserID != "" && req.Domain != "" {
+		if id.User, err = ResolveUser(ctx, req.Domain, req.UserID); err != nil {
+			return err
+		}
+	}
+	id.RateLimit = LastRateLimit()
+	return handle(ctx, id)
+}
+
+/***************************************
+ * Query Expansion
+ ***************************************/
+
+var queryToken = regexp.MustCompile(`\S+`)
+
+// ExpandQuery は、検索クエリ中の author:me をユーザーID userID に置き換える
+func ExpandQuery(query, userID string) (string, error) {
+	var found bool
+	expanded := queryToken.ReplaceAllStringFunc(query, func(token string) string {
+		switch token {
+		case "author:me":
+			found = true
+			return "author:" + userID
+		case "-author:me":
+			found = true
+			return "-author:" + userID
+		}
+		return token
+	})
+	if !found {
+		return query, nil
+	}
+	if userID == "" {
+		return "", fmt.Errorf("cannot expand 'author:me': UserID is not configured")
+	}
+	return expanded, nil
+}
+
+/***************************************
+ * Presenters
+ ***************************************/
+
+// OutputMembers は、ユーザーの一覧を format で指定された形式で出力する
+func OutputMembers(out io.Writer, format Format) MemberCollectionHandler {
+	return func(ctx context.Context, users []Member) error {
+		switch format {
+		case FormatJSON:
+			return writeJSON(out, users)
+		case FormatCSV:
+			records := make([][]string, len(users))
+			for i, u := range users {
+				records[i] = []string{strconv.Itoa(u.ID), u.Username, u.Name, u.Role, strconv.Itoa(u.PostsCount)}
+			}
+			return writeCSV(out, []string{"id", "username", "name", "role", "posts_count"}, records)
+		}
+		for _, u := range users {
+			if _, err := fmt.Fprintf(out, "%d\t%s\t%s\n", u.ID, u.Username, u.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// OutputMemberDetail は、ユーザーの詳細を format で指定された形式で出力する
+func OutputMemberDetail(out io.Writer, format Format) MemberHandler {
+	return func(ctx context.Context, u Member) error {
+		if format != FormatText {
+			return OutputMembers(out, format)(ctx, []Member{u})
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 1, ' ', 0)
+		fmt.Fprintf(w, "ID:\t%d\n", u.ID)
+		fmt.Fprintf(w, "Username:\t%s\n", u.Username)
+		fmt.Fprintf(w, "Name:\t%s\n", u.Name)
+		fmt.Fprintf(w, "Role:\t%s\n", u.Role)
+		fmt.Fprintf(w, "Posts:\t%d\n", u.PostsCount)
+		fmt.Fprintf(w, "LastAccessTime:\t%s\n", u.LastAccessTime)
+		fmt.Fprintf(w, "TwoStepAuth:\t%t\n", u.TwoStepAuthentication)
+		for i, g := range u.Groups {
+			label := ""
+			if i == 0 {
+				label = "Groups:"
+			}
+			fmt.Fprintf(w, "%s\t%s\n", label, g.Name)
+		}
+		return w.Flush()
+	}
+}
+
+// OutputIdentity は、Whoami の結果を format で指定された形式で出力する
+func OutputIdentity(out io.Writer, format Format) IdentityHandler {
+	return func(ctx context.Context, id Identity) error {
+		if format == FormatJSON {
+			return writeJSON(out, id)
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 1, ' ', 0)
+		if id.User != nil {
+			fmt.Fprintf(w, "User:\t%s (@%s, id:%d)\n", id.User.Name, id.User.Username, id.User.ID)
+		} else {
+			fmt.Fprintf(w, "User:\t(unknown: set UserID in config)\n")
+		}
+		if id.Team != nil {
+			fmt.Fprintf(w, "Team:\t%s (%s)\n", id.Team.Name, id.Team.Domain)
+		}
+		if id.Team == nil {
+			for _, t := range id.Teams {
+				fmt.Fprintf(w, "Team:\t%s (%s)\n", t.Name, t.Domain)
+			}
+		}
+		if rl := id.RateLimit; rl != nil {
+			fmt.Fprintf(w, "RateLimit:\t%d/%d remaining (resets at %s)\n",
+				rl.Remaining, rl.Limit, rl.Reset.Format(time.RFC3339))
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,107 @@
+package docbasecli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandQuery(t *testing.T) {
+	tests := []struct {
+		query, user, want string
+		wantErr           bool
+	}{
+		{query: "tag:dev", user: "", want: "tag:dev"},
+		{query: "author:me", user: "micheam", want: "author:micheam"},
+		{query: "tag:dev -author:me", user: "micheam", want: "tag:dev -author:micheam"},
+		{query: "author:me author:me", user: "micheam", want: "author:micheam author:micheam"},
+		{query: "author:meme", user: "micheam", want: "author:meme"},
+		{query: "author:me", user: "me", want: "author:me"},
+		{query: "author:me -author:me", user: "a$1b", want: "author:a$1b -author:a$1b"},
+		{query: "tag:dev  author:me", user: "micheam", want: "tag:dev  author:micheam"},
+		{query: "author:me", user: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ExpandQuery(tt.query, tt.user)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ExpandQuery(%q): unexpected error %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ExpandQuery(%q): want %q, got %q", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestWhoami(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "300")
+		w.Header().Set("X-RateLimit-Remaining", "299")
+		w.Header().Set("X-RateLimit-Reset", "1600000000")
+		switch r.URL.Path {
+		case "/teams":
+			_ = json.NewEncoder(w).Encode([]Team{{Domain: "example", Name: "Example Inc."}})
+		case "/teams/example/users":
+			if q := r.URL.Query().Get("q"); q != "micheam" {
+				t.Errorf("unexpected query: %q", q)
+			}
+			_ = json.NewEncoder(w).Encode([]Member{
+				{ID: 1, Username: "micheam2", Name: "Someone"},
+				{ID: 2, Username: "micheam", Name: "Michito Maeda"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	var got Identity
+	req := WhoamiRequest{Domain: "example", UserID: "micheam"}
+	err := Whoami(context.Background(), req, func(_ context.Context, id Identity) error {
+		got = id
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Team == nil || got.Team.Name != "Example Inc." {
+		t.Errorf("unexpected team: %+v", got.Team)
+	}
+	if got.User == nil || got.User.ID != 2 {
+		t.Errorf("unexpected user: %+v", got.User)
+	}
+	if got.RateLimit == nil || got.RateLimit.Remaining != 299 {
+		t.Errorf("unexpected rate limit: %+v", got.RateLimit)
+	}
+
+	buf := new(bytes.Buffer)
+	got.RateLimit = nil
+	if err := OutputIdentity(buf, FormatText)(context.Background(), got); err != nil {
+		t.Fatal(err)
+	}
+	want := "User: Michito Maeda (@micheam, id:2)\nTeam: Example Inc. (example)\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("output mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestResolveUser_numericID(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q, ok := r.URL.Query()["q"]; ok {
+			t.Errorf("numeric ID must not be searched by name: %q", q)
+		}
+		_ = json.NewEncoder(w).Encode([]Member{
+			{ID: 1, Username: "alice"},
+			{ID: 12345, Username: "micheam"},
+		})
+	}))
+	got, err := ResolveUser(context.Background(), "example", "12345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Username != "micheam" {
+		t.Errorf("unexpected user: %+v", got)
+	}
+}
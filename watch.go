@@ -0,0 +1,356 @@
+package docbasecli
+
+// メモの変更の監視
+//
+// 検索結果を定期的に取得し、前回から追加・更新・アーカイブされたメモを通知する。
+// 取得したメモの ID と更新日時は状態ファイルに保存し、再起動しても同じ変更を通知しない。
+// 初回 (状態ファイルがない場合) は、現在のメモを記録するだけで通知しない。
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/micheam/go-docbase"
+)
+
+// DefaultWatchInterval 検索結果を取得する間隔の既定値
+const DefaultWatchInterval = time.Minute
+
+// DefaultWebhookTimeout Webhook への送信１件あたりの制限時間の既定値
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WatchEventType は、監視で検出した変更の種類
+type WatchEventType string
+
+const (
+	WatchNew      WatchEventType = "new"
+	WatchUpdated  WatchEventType = "updated"
+	WatchArchived WatchEventType = "archived"
+)
+
+// WatchEvent は、監視で検出したメモの変更
+type WatchEvent struct {
+	Type      WatchEventType `json:"type"`
+	ID        docbase.PostID `json:"id"`
+	Title     string         `json:"title"`
+	URL       string         `json:"url"`
+	Author    string         `json:"author"`
+	Tags      []string       `json:"tags"`
+	UpdatedAt string         `json:"updated_at"`
+
+	// Text 通知用の１行の要約。Slack などの Incoming Webhook にそのまま送れるようにする
+	Text string `json:"text"`
+}
+
+func newWatchEvent(t WatchEventType, post docbase.Post) WatchEvent {
+	e := WatchEvent{
+		Type:      t,
+		ID:        post.ID,
+		Title:     post.Title,
+		URL:       post.URL,
+		Author:    post.User.Name,
+		Tags:      []string{},
+		UpdatedAt: post.UpdatedAt,
+		Text:      fmt.Sprintf("[%s] %s %s", t, post.Title, post.URL),
+	}
+	for _, tag := range post.Tags {
+		e.Tags = append(e.Tags, tag.Name)
+	}
+	return e
+}
+
+type WatchEventHandler func(ctx context.Context, event WatchEvent) error
+
+// WatchState は、前回までに取得したメモの ID と更新日時
+type WatchState struct {
+	Query string                    `json:"query"`
+	Posts map[docbase.PostID]string `json:"posts"`
+}
+
+// WatchStatePath は、domain で query を監視する際の状態ファイルのパスを返す
+func WatchStatePath(domain, query string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(query))
+	return filepath.Join(dir, domain, "watch", hex.EncodeToString(sum[:])[:12]+".json"), nil
+}
+
+// LoadWatchState は、状態ファイルを読み込む。ファイルがない場合は nil を返す。
+func LoadWatchState(path string) (*WatchState, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s WatchState
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse watch state %q: %w", path, err)
+	}
+	if s.Posts == nil {
+		s.Posts = map[docbase.PostID]string{}
+	}
+	return &s, nil
+}
+
+func saveWatchState(path string, s *WatchState) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0o600)
+}
+
+// DiffWatch は、state と今回取得した posts を比べて変更を返し、state を更新する。
+// 検索結果から消えたメモの ID は gone として返す (state からは削除しない)。
+func DiffWatch(state *WatchState, posts []docbase.Post) (events []WatchEvent, gone []docbase.PostID) {
+	found := map[docbase.PostID]bool{}
+	for _, post := range posts {
+		found[post.ID] = true
+		prev, seen := state.Posts[post.ID]
+		switch {
+		case !seen:
+			events = append(events, newWatchEvent(WatchNew, post))
+		case post.Archived && prev != "archived":
+			events = append(events, newWatchEvent(WatchArchived, post))
+		case !post.Archived && prev != post.UpdatedAt:
+			events = append(events, newWatchEvent(WatchUpdated, post))
+		}
+		state.Posts[post.ID] = watchMark(post)
+	}
+	for id := range state.Posts {
+		if !found[id] {
+			gone = append(gone, id)
+		}
+	}
+	sort.Slice(gone, func(i, j int) bool { return gone[i] < gone[j] })
+	return events, gone
+}
+
+// watchMark は、状態ファイルに記録する値 (アーカイブされたメモは "archived")
+func watchMark(post docbase.Post) string {
+	if post.Archived {
+		return "archived"
+	}
+	return post.UpdatedAt
+}
+
+type WatchRequest struct {
+	Domain   string
+	Query    string
+	Interval time.Duration
+
+	// StatePath 状態ファイルのパス。省略した場合は WatchStatePath
+	StatePath string
+
+	// Once 指定した場合は１回だけ取得して終了する (cron などから実行する場合)
+	Once bool
+
+	// OnError ２回目以降の取得に失敗した場合に呼ばれる。監視は続ける。
+	OnError func(err error)
+}
+
+// Watch は、ctx が終了するまで req.Interval ごとに検索結果を取得し、変更を handle に渡す。
+// 初回の取得に失敗した場合や handle がエラーを返した場合は終了する。
+func Watch(ctx context.Context, req WatchRequest, handle WatchEventHandler) error {
+	if req.Interval <= 0 {
+		req.Interval = DefaultWatchInterval
+	}
+	path := req.StatePath
+	if path == "" {
+		var err error
+		if path, err = WatchStatePath(req.Domain, req.Query); err != nil {
+			return err
+		}
+	}
+	state, err := LoadWatchState(path)
+	if err != nil {
+		return err
+	}
+	// 別のクエリの状態を使うと、すべてのメモを新しいものとして通知してしまう
+	if state != nil && state.Query != req.Query {
+		return fmt.Errorf("watch state %q is for query %q, not %q (remove it or specify another state file)", path, state.Query, req.Query)
+	}
+	initial := state == nil
+	if initial {
+		state = &WatchState{Query: req.Query, Posts: map[docbase.PostID]string{}}
+	}
+	log.Printf("watch %q every %s (state: %s)", req.Query, req.Interval, path)
+
+	for first := true; ; first = false {
+		err := pollWatch(ctx, req, path, state, initial, handle)
+		if err == nil {
+			initial = false
+		}
+		switch {
+		case err != nil && (first || errors.Is(err, errWatchHandler)):
+			return err
+		case err != nil && req.OnError != nil:
+			req.OnError(err)
+		}
+		if req.Once {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(req.Interval):
+		}
+	}
+}
+
+var errWatchHandler = errors.New("watch handler failed")
+
+// pollWatch は、検索結果を１回取得して変更を handle に渡し、状態ファイルを更新する。
+// initial の場合は、現在のメモを記録するだけで通知しない。
+func pollWatch(ctx context.Context, req WatchRequest, path string, state *WatchState, initial bool, handle WatchEventHandler) error {
+	posts, err := CollectPosts(ctx, ListPostsRequest{Domain: req.Domain, Query: &req.Query})
+	if err != nil {
+		return err
+	}
+	events, err := updateWatchState(ctx, req.Domain, path, state, posts, initial)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := handle(ctx, e); err != nil {
+			return fmt.Errorf("%w: %v", errWatchHandler, err)
+		}
+	}
+	return nil
+}
+
+// updateWatchState は、posts と state の差分から変更を求め、更新した状態を保存してから state に反映する。
+// 途中で失敗した場合は、次回の取得で同じ変更を通知できるよう state を変更しない。
+// 通知の途中で失敗しても同じ変更を再び通知しないよう、状態は通知の前に保存する。
+func updateWatchState(ctx context.Context, domain, path string, state *WatchState, posts []docbase.Post, initial bool) ([]WatchEvent, error) {
+	next := &WatchState{Query: state.Query, Posts: make(map[docbase.PostID]string, len(state.Posts))}
+	for id, mark := range state.Posts {
+		next.Posts[id] = mark
+	}
+	events, gone := DiffWatch(next, posts)
+	if initial {
+		log.Printf("record %d posts as initial state", len(posts))
+		events = nil
+	}
+	for _, id := range gone {
+		// 検索結果から消えたメモは、アーカイブされた場合のみ通知し、以降は追跡しない
+		if next.Posts[id] != "archived" {
+			post, err := fetchPost(ctx, domain, id)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+			if post != nil && post.Archived {
+				events = append(events, newWatchEvent(WatchArchived, *post))
+			}
+		}
+		delete(next.Posts, id)
+	}
+	if err := saveWatchState(path, next); err != nil {
+		return nil, err
+	}
+	*state = *next
+	return events, nil
+}
+
+// fetchPost は、メモ id を取得する。存在しない場合は ErrNotFound を返す。
+func fetchPost(ctx context.Context, domain string, id docbase.PostID) (*docbase.Post, error) {
+	r, err := newRequest(ctx, http.MethodGet, buildURL("teams", domain, "posts", id.String()), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var post docbase.Post
+	if err := RetryOnRateLimit(ctx, func() error { return doRequest(r, &post) }); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+/***************************************
+ * Handlers
+ ***************************************/
+
+// OutputWatchEvent は、変更を１件１行の JSON (NDJSON) で出力する
+func OutputWatchEvent(out io.Writer) WatchEventHandler {
+	enc := json.NewEncoder(out)
+	return func(ctx context.Context, e WatchEvent) error {
+		return enc.Encode(e)
+	}
+}
+
+// ExecWatchEvent は、変更ごとに command を sh -c で実行する。
+// 変更は JSON で標準入力に、種類と対象のメモは環境変数で渡す。
+// コマンドの出力は標準エラー出力に書き出す。
+func ExecWatchEvent(command string) WatchEventHandler {
+	return func(ctx context.Context, e WatchEvent) error {
+		in, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stdin = bytes.NewReader(in)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(),
+			"DOCBASE_EVENT="+string(e.Type),
+			"DOCBASE_POST_ID="+e.ID.String(),
+			"DOCBASE_POST_TITLE="+e.Title,
+			"DOCBASE_POST_URL="+e.URL,
+		)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%q failed for post(%d): %w", command, e.ID, err)
+		}
+		return nil
+	}
+}
+
+// PostWatchEvent は、変更を JSON で url に POST する。
+// timeout は１件あたりの制限時間で、0 の場合は DefaultWebhookTimeout
+func PostWatchEvent(url string, timeout time.Duration) WatchEventHandler {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return func(ctx context.Context, e WatchEvent) error {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		log.Println(req.Method, req.URL)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returns %s for post(%d)", resp.Status, e.ID)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,215 @@
+package docbasecli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/micheam/go-docbase"
+)
+
+func TestDiffWatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      map[docbase.PostID]string
+		posts      []docbase.Post
+		wantEvents []WatchEventType
+		wantGone   []docbase.PostID
+		wantState  map[docbase.PostID]string
+	}{
+		{
+			name:      "no changes",
+			state:     map[docbase.PostID]string{1: "2021-04-01T10:00:00+09:00"},
+			posts:     []docbase.Post{{ID: 1, UpdatedAt: "2021-04-01T10:00:00+09:00"}},
+			wantState: map[docbase.PostID]string{1: "2021-04-01T10:00:00+09:00"},
+		},
+		{
+			name:       "new and updated",
+			state:      map[docbase.PostID]string{1: "2021-04-01T10:00:00+09:00"},
+			posts:      []docbase.Post{{ID: 2, UpdatedAt: "2021-04-03T10:00:00+09:00"}, {ID: 1, UpdatedAt: "2021-04-02T10:00:00+09:00"}},
+			wantEvents: []WatchEventType{WatchNew, WatchUpdated},
+			wantState:  map[docbase.PostID]string{1: "2021-04-02T10:00:00+09:00", 2: "2021-04-03T10:00:00+09:00"},
+		},
+		{
+			name:       "archived in results",
+			state:      map[docbase.PostID]string{1: "2021-04-01T10:00:00+09:00", 2: "archived"},
+			posts:      []docbase.Post{{ID: 1, Archived: true}, {ID: 2, Archived: true}},
+			wantEvents: []WatchEventType{WatchArchived},
+			wantState:  map[docbase.PostID]string{1: "archived", 2: "archived"},
+		},
+		{
+			name:      "gone",
+			state:     map[docbase.PostID]string{3: "2021-04-01T10:00:00+09:00", 1: "2021-04-01T10:00:00+09:00"},
+			wantGone:  []docbase.PostID{1, 3},
+			wantState: map[docbase.PostID]string{3: "2021-04-01T10:00:00+09:00", 1: "2021-04-01T10:00:00+09:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &WatchState{Posts: tt.state}
+			events, gone := DiffWatch(state, tt.posts)
+			var types []WatchEventType
+			for _, e := range events {
+				types = append(types, e.Type)
+			}
+			if diff := cmp.Diff(tt.wantEvents, types); diff != "" {
+				t.Errorf("events mismatch (-want, +got):%s\n", diff)
+			}
+			if diff := cmp.Diff(tt.wantGone, gone); diff != "" {
+				t.Errorf("gone mismatch (-want, +got):%s\n", diff)
+			}
+			if diff := cmp.Diff(tt.wantState, state.Posts); diff != "" {
+				t.Errorf("state mismatch (-want, +got):%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestLoadWatchState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch", "state.json")
+	got, err := LoadWatchState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("want nil for missing state, got %v", got)
+	}
+
+	want := &WatchState{Query: "tag:foo", Posts: map[docbase.PostID]string{1: "archived"}}
+	if err := saveWatchState(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err = LoadWatchState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LoadWatchState mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestPostWatchEvent(t *testing.T) {
+	var got WatchEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	post := docbase.Post{ID: 1, Title: "Index", URL: "https://example.docbase.io/posts/1", Tags: []docbase.Tag{{Name: "foo"}}}
+	want := WatchEvent{Type: WatchNew, ID: 1, Title: "Index", URL: post.URL, Tags: []string{"foo"},
+		Text: "[new] Index https://example.docbase.io/posts/1"}
+	if err := PostWatchEvent(ts.URL+"/ok", 0)(context.Background(), newWatchEvent(WatchNew, post)); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("posted event mismatch (-want, +got):%s\n", diff)
+	}
+	if err := PostWatchEvent(ts.URL+"/fail", 0)(context.Background(), want); err == nil {
+		t.Error("want error for failed webhook")
+	}
+}
+
+func TestPostWatchEvent_timeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	start := time.Now()
+	err := PostWatchEvent(ts.URL, 50*time.Millisecond)(context.Background(), WatchEvent{Type: WatchNew, ID: 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("webhook must time out, but took %s", elapsed)
+	}
+}
+
+func TestWatch_queryMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := saveWatchState(path, &WatchState{Query: "tag:foo", Posts: map[docbase.PostID]string{}}); err != nil {
+		t.Fatal(err)
+	}
+	req := WatchRequest{Domain: "example", Query: "tag:bar", StatePath: path, Once: true}
+	err := Watch(context.Background(), req, func(context.Context, WatchEvent) error {
+		t.Error("no events must be reported")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), `is for query "tag:foo"`) {
+		t.Errorf("want query mismatch error, got %v", err)
+	}
+}
+
+func TestUpdateWatchState_retry(t *testing.T) {
+	fail := true
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(docbase.Post{ID: 1, Archived: true})
+	}))
+	path := filepath.Join(t.TempDir(), "state.json")
+	state := &WatchState{Query: "tag:foo", Posts: map[docbase.PostID]string{1: "2021-04-01T10:00:00+09:00"}}
+	posts := []docbase.Post{{ID: 2, UpdatedAt: "2021-04-02T10:00:00+09:00"}}
+
+	// 消えたメモの取得に失敗した場合は、状態を変えずに次回の取得で通知する
+	if _, err := updateWatchState(context.Background(), "example", path, state, posts, false); err == nil {
+		t.Fatal("want error for failed lookup")
+	}
+	want := map[docbase.PostID]string{1: "2021-04-01T10:00:00+09:00"}
+	if diff := cmp.Diff(want, state.Posts); diff != "" {
+		t.Errorf("state mismatch (-want, +got):%s\n", diff)
+	}
+
+	fail = false
+	events, err := updateWatchState(context.Background(), "example", path, state, posts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []WatchEventType
+	for _, e := range events {
+		got = append(got, e.Type)
+	}
+	if diff := cmp.Diff([]WatchEventType{WatchNew, WatchArchived}, got); diff != "" {
+		t.Errorf("events mismatch (-want, +got):%s\n", diff)
+	}
+	saved, err := LoadWatchState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(state, saved); diff != "" {
+		t.Errorf("saved state mismatch (-want, +got):%s\n", diff)
+	}
+}
+
+func TestUpdateWatchState_saveError(t *testing.T) {
+	// 親が通常のファイルのため保存できない
+	parent := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(parent, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	state := &WatchState{Query: "tag:foo", Posts: map[docbase.PostID]string{}}
+	posts := []docbase.Post{{ID: 2, UpdatedAt: "2021-04-02T10:00:00+09:00"}}
+	if _, err := updateWatchState(context.Background(), "example", filepath.Join(parent, "state.json"), state, posts, false); err == nil {
+		t.Fatal("want error for failed save")
+	}
+	if len(state.Posts) != 0 {
+		t.Errorf("state must not be changed, got %v", state.Posts)
+	}
+}